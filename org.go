@@ -15,19 +15,57 @@ const (
 type Option func(*parser.Document)
 
 func New(r io.Reader, opts ...Option) *parser.Document {
+	d, _ := Parse(r, opts...)
+	return d
+}
+
+// Parse is like New, but also returns the first error of the document
+// diagnostics, such as a failed read.
+func Parse(r io.Reader, opts ...Option) (*parser.Document, error) {
 	d := &parser.Document{
 		Sections: &parser.Section{},
 		Keywords: map[string]string{
 			"TODO": todoKeywords,
 		},
-		Hyperlinks:      []string{"http", "https", "file"},
+		LinkHandlers:    parser.DefaultLinkHandlers(),
 		TimestampFormat: timestampFormat,
 	}
 	for _, opt := range opts {
 		opt(d)
 	}
 	d.Children = parser.Parse(d, r)
-	return d
+	return d, d.Err()
+}
+
+// WithMacro registers a macro which can be used as {{{name(args)}}}
+func WithMacro(name string, fn parser.MacroFunc) Option {
+	return func(d *parser.Document) {
+		d.SetMacro(name, fn)
+	}
+}
+
+// WithLinkAbbrev registers a link abbreviation like #+LINK, which can be
+// used as [[name:tag]]
+func WithLinkAbbrev(name, replacement string) Option {
+	return func(d *parser.Document) {
+		d.SetLinkAbbrev(name, replacement)
+	}
+}
+
+// WithLinkHandler registers the handler of links of protocol, such as
+// WithLinkHandler("doi", parser.PrefixLinkHandler("https://doi.org/"))
+func WithLinkHandler(protocol string, fn parser.LinkHandler) Option {
+	return func(d *parser.Document) {
+		d.SetLinkHandler(protocol, fn)
+	}
+}
+
+// WithFS sets the file system which #+INCLUDE and #+SETUPFILE read, such
+// as parser.Dir(".")
+func WithFS(fs parser.FileSystem) Option {
+	return func(d *parser.Document) {
+		d.FS = fs
+	}
 }
 
 func HTML(r io.Reader, opts ...Option) string {
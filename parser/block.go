@@ -18,9 +18,12 @@ var (
 )
 
 type Block struct {
+	Span
+
 	Type       string
 	Parameters []string
 	Result     *BlockResult
+	Affiliated *Affiliated
 	Children   []Node
 }
 
@@ -29,6 +32,8 @@ func (Block) Name() string {
 }
 
 type BlockResult struct {
+	Span
+
 	Children []Node
 }
 
@@ -43,6 +48,7 @@ func (s *parser) ParseBlock(d *Document, lines []string) (*Block, int) {
 	}
 	blockType := strings.ToUpper(match[2])
 
+	line, column := s.line, s.column
 	idx, end := 1, len(lines)
 	for idx < end {
 		if m := endBlockRegexp.FindStringSubmatch(lines[idx]); m != nil && strings.ToUpper(m[2]) == blockType {
@@ -52,11 +58,13 @@ func (s *parser) ParseBlock(d *Document, lines []string) (*Block, int) {
 			if params := strings.TrimSpace(match[3]); params != "" {
 				b.Parameters = strings.Split(params, " ")
 			}
+			s.seekLine(line, column, 1)
 			switch blockType {
 			case "VERSE":
 				b.Children = s.ParseAllInline(d, strings.Join(lines[1:idx], "\n"), false)
 			case "SRC", "EXAMPLE":
 				b.Children = s.ParseAll(d, lines[1:idx], true)
+				unescapeBlock(b.Children)
 			case "EXPORT":
 				if len(b.Parameters) > 0 && strings.ToUpper(b.Parameters[0]) == "ORG" {
 					b.Children = s.ParseAll(d, lines[1:idx], false)
@@ -70,21 +78,54 @@ func (s *parser) ParseBlock(d *Document, lines []string) (*Block, int) {
 		}
 		idx++
 	}
+	s.warn(d, line, column, "unterminated block #+BEGIN_%s", blockType)
 	return nil, 0
 }
 
+// unescapeBlock removes the comma before "*" and "#+" of the lines in src
+// and example blocks, which is used to escape the lines like heading or
+// #+END_SRC
+func unescapeBlock(nodes []Node) {
+	for _, node := range nodes {
+		if n, ok := node.(*InlineText); ok {
+			n.Content = exampleBlockEscapeRegexp.ReplaceAllString(n.Content, "$1$2$3$4")
+		}
+	}
+}
+
+// outsideBlocks returns the indexes of lines which are not in blocks, the
+// keywords in blocks such as #+BEGIN_EXAMPLE are the content of blocks.
+func outsideBlocks(lines []string) []int {
+	indexes := make([]int, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		indexes = append(indexes, i)
+		match := beginBlockRegexp.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if m := endBlockRegexp.FindStringSubmatch(lines[j]); m != nil && strings.EqualFold(m[2], match[2]) {
+				i = j
+				break
+			}
+		}
+	}
+	return indexes
+}
+
 func (s *parser) ParseBlockResult(d *Document, lines []string) (*BlockResult, int) {
 	match := resultRegexp.FindStringSubmatch(lines[0])
 	if match == nil {
 		return nil, 0
 	}
+	line, column := s.line, s.column
 
-	idx, end := 1, len(lines)
-	for idx < end {
-		if match := resultRegexp.FindStringSubmatch(lines[idx]); match == nil {
-			return &BlockResult{s.ParseAll(d, lines[1:idx], false)}, idx + 1
+	if len(lines) > 1 && !isBlankline(lines[1]) && !headingRegexp.MatchString(lines[1]) {
+		s.seekLine(line, column, 1)
+		if node, idx := s.Parse(d, lines[1:]); node != nil {
+			return &BlockResult{Children: []Node{node}}, idx + 1
 		}
-		idx++
 	}
-	return nil, 0
+	s.warn(d, line, column, "#+RESULTS: is not followed by a result")
+	return &BlockResult{}, 1
 }
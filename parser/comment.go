@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+const CommentName = "Comment"
+
+var commentRegexp = regexp.MustCompile(`^(\s*)#(?:\s(.*)|$)`)
+
+// Comment is consecutive "# " lines or a #+BEGIN_COMMENT block
+type Comment struct {
+	Span
+
+	Block   bool
+	Content string
+}
+
+func (Comment) Name() string {
+	return CommentName
+}
+
+func (s *parser) ParseComment(d *Document, lines []string) (*Comment, int) {
+	if match := beginBlockRegexp.FindStringSubmatch(lines[0]); match != nil {
+		if strings.ToUpper(match[2]) != "COMMENT" {
+			return nil, 0
+		}
+		for idx := 1; idx < len(lines); idx++ {
+			if m := endBlockRegexp.FindStringSubmatch(lines[idx]); m != nil && strings.ToUpper(m[2]) == "COMMENT" {
+				return &Comment{Block: true, Content: strings.Join(lines[1:idx], "\n")}, idx + 1
+			}
+		}
+		// unterminated block is reported by ParseBlock
+		return nil, 0
+	}
+
+	contents := make([]string, 0)
+	for _, line := range lines {
+		m := commentRegexp.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+		contents = append(contents, m[2])
+	}
+	if len(contents) == 0 {
+		return nil, 0
+	}
+	return &Comment{Content: strings.Join(contents, "\n")}, len(contents)
+}
@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment(t *testing.T) {
+	text := `text
+# note
+#
+#+begin_comment
+block
+#+end_comment
+* TODO COMMENT Draft :tag:`
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	assert.Equal(t, ParagraghName, nodes[0].Name())
+	comment := nodes[1].(*Comment)
+	assert.Equal(t, "note\n", comment.Content)
+	assert.Equal(t, Span{Position{2, 1, 5}, Position{3, 2, 13}}, comment.Pos())
+
+	block := nodes[2].(*Comment)
+	assert.True(t, block.Block)
+	assert.Equal(t, "block", block.Content)
+
+	heading := nodes[3].(*Heading)
+	assert.True(t, heading.Commented)
+	assert.Equal(t, "TODO", heading.Keyword)
+	assert.Equal(t, "Draft", heading.Title[0].(*InlineText).Content)
+	assert.Equal(t, []string{"tag"}, heading.Tags)
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cookieData returns the COOKIE_DATA property of heading, it is inherited
+// from the ancestors or #+PROPERTY
+func (d *Document) cookieData(heading *Heading) string {
+	if heading == nil {
+		v, _ := d.Property("COOKIE_DATA")
+		return v
+	}
+	v, _ := heading.Property("COOKIE_DATA", true)
+	return v
+}
+
+// updateCookies computes the [n/m] and [%] statistics cookies of headings
+// and list items, headings count the TODO states of their child headings
+// or the checkboxes of their lists, list items count the checkboxes of
+// their child items. COOKIE_DATA may contain "todo" or "checkbox" to
+// choose what a heading counts, and "recursive" to count all descendants.
+func (s *parser) updateCookies(d *Document, nodes []Node, heading *Heading) {
+	data := d.cookieData(heading)
+	recursive := strings.Contains(data, "recursive")
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Heading:
+			if hasCookie(n.Title) {
+				done, total := headingStats(d, n)
+				setCookies(n.Title, done, total)
+			}
+			s.updateCookies(d, n.Children, n)
+		case *List:
+			for _, child := range n.Children {
+				switch item := child.(type) {
+				case *ListItem:
+					if hasCookie(item.Title) {
+						done, total := countCheckbox(item.Children, recursive)
+						setCookies(item.Title, done, total)
+					}
+					s.updateCookies(d, item.Children, heading)
+				case *DescriptiveItem:
+					if hasCookie(item.Title) {
+						done, total := countCheckbox(item.Children, recursive)
+						setCookies(item.Title, done, total)
+					}
+					s.updateCookies(d, item.Children, heading)
+				}
+			}
+		}
+	}
+}
+
+func headingStats(d *Document, heading *Heading) (int, int) {
+	data := d.cookieData(heading)
+	recursive := strings.Contains(data, "recursive")
+	if !strings.Contains(data, "checkbox") {
+		done, total := countTodo(d, heading.Children, recursive)
+		if total > 0 || strings.Contains(data, "todo") {
+			return done, total
+		}
+	}
+	return countCheckbox(heading.Children, recursive)
+}
+
+func hasCookie(nodes []Node) bool {
+	for _, node := range nodes {
+		if _, ok := node.(*InlinePercent); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func setCookies(nodes []Node, done, total int) {
+	for _, node := range nodes {
+		n, ok := node.(*InlinePercent)
+		if !ok {
+			continue
+		}
+		if !strings.HasSuffix(n.Num, "%") {
+			n.Num = fmt.Sprintf("%d/%d", done, total)
+		} else if total == 0 {
+			n.Num = "0%"
+		} else {
+			n.Num = fmt.Sprintf("%d%%", done*100/total)
+		}
+	}
+}
+
+func countTodo(d *Document, nodes []Node, recursive bool) (int, int) {
+	done, total := 0, 0
+	for _, node := range nodes {
+		n, ok := node.(*Heading)
+		if !ok {
+			continue
+		}
+		if n.Keyword != "" {
+			total++
+			if n.IsDone() {
+				done++
+			}
+		}
+		if recursive {
+			cdone, ctotal := countTodo(d, n.Children, recursive)
+			done, total = done+cdone, total+ctotal
+		}
+	}
+	return done, total
+}
+
+func countCheckbox(nodes []Node, recursive bool) (int, int) {
+	done, total := 0, 0
+	for _, node := range nodes {
+		n, ok := node.(*List)
+		if !ok {
+			continue
+		}
+		for _, child := range n.Children {
+			var status string
+			var children []Node
+			switch item := child.(type) {
+			case *ListItem:
+				status, children = item.Status, item.Children
+			case *DescriptiveItem:
+				status, children = item.Status, item.Children
+			}
+			if status != "" {
+				total++
+				if status == "X" {
+					done++
+				}
+			}
+			if recursive {
+				cdone, ctotal := countCheckbox(children, recursive)
+				done, total = done+cdone, total+ctotal
+			}
+		}
+	}
+	return done, total
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookies(t *testing.T) {
+	text := `* Project [/] [%]
+  :PROPERTIES:
+  :COOKIE_DATA: todo recursive
+  :END:
+** TODO a
+*** DONE b
+** Notes [%]
+   :PROPERTIES:
+   :COOKIE_DATA: checkbox recursive
+   :END:
+- [X] one
+  - [ ] two
+* Plain [/]
+- [X] x [/]
+  - [-] y
+  - [X] z`
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	cookies := func(nodes []Node) []string {
+		var nums []string
+		for _, node := range nodes {
+			if n, ok := node.(*InlinePercent); ok {
+				nums = append(nums, n.Num)
+			}
+		}
+		return nums
+	}
+
+	project := nodes[0].(*Heading)
+	assert.Equal(t, []string{"1/2", "50%"}, cookies(project.Title))
+
+	notes := project.Children[1].(*Heading)
+	assert.Equal(t, []string{"50%"}, cookies(notes.Title))
+
+	plain := nodes[1].(*Heading)
+	assert.Equal(t, []string{"1/1"}, cookies(plain.Title))
+	item := plain.Children[0].(*List).Children[0].(*ListItem)
+	assert.Equal(t, []string{"1/2"}, cookies(item.Title))
+}
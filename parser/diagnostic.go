@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+// Diagnostic is a problem found while parsing, the document is still
+// parsed as well as possible.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Pos      Position
+	// File is the included file which Pos refers to, or empty for the
+	// document itself
+	File string
+}
+
+func (s Diagnostic) Error() string {
+	if s.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", s.File, s.Pos.Line, s.Pos.Column, s.Severity, s.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", s.Pos.Line, s.Pos.Column, s.Severity, s.Message)
+}
+
+// Err returns the first diagnostic with error severity
+func (d *Document) Err() error {
+	for _, diag := range d.Diagnostics {
+		if diag.Severity == SeverityError {
+			return diag
+		}
+	}
+	return nil
+}
+
+func (d *Document) diagnose(severity Severity, pos Position, format string, args ...interface{}) {
+	diag := Diagnostic{
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Pos:      pos,
+		File:     d.currentFile(),
+	}
+	// some lines may be parsed more than once
+	for _, old := range d.Diagnostics {
+		if old == diag {
+			return
+		}
+	}
+	d.Diagnostics = append(d.Diagnostics, diag)
+}
+
+func (s *parser) warn(d *Document, line, column int, format string, args ...interface{}) {
+	d.diagnose(SeverityWarning, s.position(line, column), format, args...)
+}
+
+// sortDiagnostics orders the diagnostics by file and position, since the
+// post-parse passes report them after the whole document is parsed.
+func (d *Document) sortDiagnostics() {
+	sort.SliceStable(d.Diagnostics, func(i, j int) bool {
+		a, b := d.Diagnostics[i], d.Diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Pos.Offset < b.Pos.Offset
+	})
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"bufio"
 	"io"
+	"regexp"
 	"strings"
 	"sync"
 )
@@ -16,6 +17,7 @@ var pool = sync.Pool{
 type (
 	Node interface {
 		Name() string
+		Pos() Span
 	}
 	Parser interface {
 		Parse(*Document, []string) (Node, int)
@@ -28,31 +30,140 @@ type (
 		Sections        *Section
 		Keywords        map[string]string
 		Properties      map[string]string
-		Hyperlinks      []string
+		LinkHandlers    map[string]LinkHandler
 		TimestampFormat string
+		Macros          map[string]MacroFunc
+		LinkAbbrevs     map[string]string
+		TodoSequences   []TodoSequence
+		FS              FileSystem
+		Diagnostics     []Diagnostic
+
+		// Deprecated: use LinkHandlers instead. The protocols of
+		// Hyperlinks are recognized as links even without handlers, and
+		// the protocols of LinkHandlers are added to it when the document
+		// is parsed.
+		Hyperlinks []string
+
+		// the files being included, the last one is the current file
+		includes []string
+		// the options of #+OPTIONS, which are computed once the settings
+		// are collected
+		options *Options
 	}
 )
 
+// Position is a location in the source text. Line and Column start
+// from 1, Column and Offset are counted in bytes.
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// Span is the source range of a node, End is exclusive.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) Pos() Span {
+	return s
+}
+
+func (s *Span) setSpan(start, end Position) {
+	s.Start, s.End = start, end
+}
+
 func isBlankline(line string) bool {
 	return strings.TrimLeft(line, " ") == ""
 }
 
+// lineIndent returns the width of leading whitespace, tab stops
+// every 8 columns like org-mode
 func lineIndent(line string) int {
-	return len(line) - len(strings.TrimLeft(line, " "))
+	n := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			n++
+		case '\t':
+			n += 8 - n%8
+		default:
+			return n
+		}
+	}
+	return n
 }
 
+// Get returns the value of keyword k, k is case insensitive
 func (d *Document) Get(k string) string {
-	return d.Keywords[k]
+	return d.Keywords[strings.ToUpper(k)]
 }
 
 func (d *Document) Set(k, v string) {
-	d.Keywords[k] = v
+	d.Keywords[strings.ToUpper(k)] = v
+}
+
+// advance returns the line and column after text which starts at line and column
+func advance(line, column int, text string) (int, int) {
+	if n := strings.Count(text, "\n"); n > 0 {
+		return line + n, len(text) - strings.LastIndexByte(text, '\n') - 1
+	}
+	return line, column + len(text)
+}
+
+// Property returns the value of a #+PROPERTY keyword, key is case insensitive
+func (d *Document) Property(key string) (string, bool) {
+	v, ok := d.Properties[strings.ToUpper(key)]
+	return v, ok
+}
+
+var settingRegexp = regexp.MustCompile(`(?i)^\s*#\+(TODO|SEQ_TODO|TYP_TODO|PRIORITIES|OPTIONS|SETUPFILE|INCLUDE):(?:\s(.*)|$)`)
+
+// parseSettings collects the keywords which change how the document is
+// parsed, such as #+TODO, #+PRIORITIES, #+OPTIONS, #+SETUPFILE and the
+// settings of #+INCLUDE files, so
+// they apply to the text before them too, the keywords in blocks are
+// skipped.
+func (s *parser) parseSettings(d *Document, lines []string) {
+	for _, i := range outsideBlocks(lines) {
+		match := settingRegexp.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		switch key := strings.ToUpper(match[1]); key {
+		case "PRIORITIES":
+			d.Set(key, strings.TrimSpace(match[2]))
+		case "OPTIONS":
+			d.Set(key, strings.TrimSpace(d.Get(key)+" "+match[2]))
+		case "SETUPFILE":
+			s.parseSetupFile(d, i, match[2])
+		case "INCLUDE":
+			s.parseIncludeSettings(d, match[2])
+		default:
+			d.AddTodoSequence(key, match[2])
+		}
+	}
 }
 
 func ParseFromLines(d *Document, lines []string) []Node {
 	p := pool.Get().(*parser)
 	defer pool.Put(p)
-	return p.ParseAll(d, lines, false)
+
+	p.offsets = lineOffsets(lines)
+	p.seek(0, 0)
+	d.syncHyperlinks()
+	p.parseSettings(d, lines)
+	opts := d.Options()
+	d.options = &opts
+	nodes := p.ParseAll(d, lines, false)
+	p.updateCookies(d, nodes, nil)
+	p.expandMacros(d, nodes)
+	p.expandLinks(d, nodes)
+	nodes = p.resolveLinks(d, nodes)
+	d.markExported()
+	d.sortDiagnostics()
+	return nodes
 }
 
 func ParseFromText(d *Document, text string) []Node {
@@ -60,18 +171,90 @@ func ParseFromText(d *Document, text string) []Node {
 }
 
 func Parse(d *Document, r io.Reader) []Node {
-	scanner := bufio.NewScanner(r)
+	reader := bufio.NewReader(r)
 
-	lines := make([]string, 0)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	lines, offset := make([]string, 0), 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			offset = offset + len(line)
+			lines = append(lines, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			pos := Position{Line: len(lines) + 1, Column: 1, Offset: offset}
+			d.diagnose(SeverityError, pos, "read error: %s", err)
+			break
+		}
 	}
 	return ParseFromLines(d, lines)
 }
 
-type parser struct{}
+type parser struct {
+	// byte offset of each source line
+	offsets []int
+	// source line and column of the text being parsed, the column only
+	// applies to the first line
+	line   int
+	column int
+}
+
+func (s *parser) seek(line, column int) {
+	s.line, s.column = line, column
+}
+
+// seekLine moves to the idx-th line of the lines which start at line and column
+func (s *parser) seekLine(line, column, idx int) {
+	if idx > 0 {
+		column = 0
+	}
+	s.seek(line+idx, column)
+}
+
+func (s *parser) position(line, column int) Position {
+	offset := column
+	if line < len(s.offsets) {
+		offset = offset + s.offsets[line]
+	}
+	return Position{Line: line + 1, Column: column + 1, Offset: offset}
+}
+
+// setPos records the source range of node, which starts at line and column and covers text
+func (s *parser) setPos(node Node, line, column int, text string) {
+	n, ok := node.(interface{ setSpan(Position, Position) })
+	if !ok {
+		return
+	}
+	eline, ecolumn := advance(line, column, text)
+	n.setSpan(s.position(line, column), s.position(eline, ecolumn))
+}
+
+// setLinesPos is like setPos, but node covers whole lines
+func (s *parser) setLinesPos(node Node, line, column int, lines []string) {
+	n, ok := node.(interface{ setSpan(Position, Position) })
+	if !ok || len(lines) == 0 {
+		return
+	}
+	last := len(lines) - 1
+	ecolumn := len(lines[last])
+	if last == 0 {
+		ecolumn = ecolumn + column
+	}
+	n.setSpan(s.position(line, column), s.position(line+last, ecolumn))
+}
 
 func (s *parser) Parse(d *Document, lines []string) (Node, int) {
+	line, column := s.line, s.column
+	node, idx := s.parse(d, lines)
+	if node != nil {
+		s.setLinesPos(node, line, column, lines[:idx])
+	}
+	return node, idx
+}
+
+func (s *parser) parse(d *Document, lines []string) (Node, int) {
 	if node, idx := s.ParseBlankLine(d, lines); node != nil {
 		return node, idx
 	}
@@ -84,15 +267,30 @@ func (s *parser) Parse(d *Document, lines []string) (Node, int) {
 	if node, idx := s.ParseList(d, lines); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseFixedWidth(d, lines); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseDrawer(d, lines); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseComment(d, lines); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseBlock(d, lines); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseLatexEnvironment(d, lines); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseBlockResult(d, lines); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseExportKeyword(d, lines); node != nil {
+		return node, idx
+	}
+	if node, idx := s.ParseInclude(d, lines); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseKeyword(d, lines); node != nil {
 		return node, idx
 	}
@@ -109,21 +307,38 @@ func (s *parser) ParseAll(d *Document, lines []string, raw bool) []Node {
 	if raw && len(lines) > 0 {
 		return s.ParseAllInline(d, strings.Join(lines, "\n"), raw)
 	}
+	var aff *Affiliated
+
+	line, column := s.line, s.column
 	idx, end, nodes := 0, len(lines), make([]Node, 0)
 	for idx < end {
+		s.seekLine(line, column, idx)
+		if aff == nil {
+			if node, i := s.ParseAffiliated(d, lines[idx:]); node != nil {
+				aff, idx = node, idx+i
+				continue
+			}
+		}
 		if node, i := s.Parse(d, lines[idx:]); node != nil {
 			idx = idx + i
-			nodes = append(nodes, node)
+			nodes = append(nodes, affiliate(node, aff)...)
+			aff = nil
 			continue
 		}
 		node, next, i := s.ParseParagragh(d, lines[idx:])
 		if node != nil {
-			nodes = append(nodes, node)
+			nodes = append(nodes, affiliate(node, aff)...)
+			aff = nil
 		}
 		if next != nil {
 			nodes = append(nodes, next)
 		}
 		idx = idx + i
 	}
+	if aff != nil {
+		for _, keyword := range aff.Keywords {
+			nodes = append(nodes, keyword)
+		}
+	}
 	return nodes
 }
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDocument() *Document {
+	return &Document{
+		Sections: &Section{},
+		Keywords: map[string]string{
+			"TODO": "TODO | DONE | CANCELED",
+		},
+		LinkHandlers:    DefaultLinkHandlers(),
+		TimestampFormat: "2006-01-02 Mon 15:04",
+	}
+}
+
+func TestPosition(t *testing.T) {
+	text := `* TODO heading *bold*
+  - item [[https://orgmode.org][link]]
+
+| a | b |
+|---+---|
+| c | d |`
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	heading := nodes[0].(*Heading)
+	assert.Equal(t, Span{Position{1, 1, 0}, Position{6, 10, 91}}, heading.Pos())
+	assert.Equal(t, Span{Position{1, 16, 15}, Position{1, 22, 21}}, heading.Title[1].Pos())
+
+	list := heading.Children[0].(*List)
+	assert.Equal(t, Span{Position{2, 1, 22}, Position{3, 1, 61}}, list.Pos())
+
+	item := list.Children[0].(*ListItem)
+	link := item.Title[1]
+	assert.Equal(t, InlineLinkName, link.Name())
+	assert.Equal(t, Span{Position{2, 10, 31}, Position{2, 39, 60}}, link.Pos())
+
+	table := heading.Children[1].(*Table)
+	assert.Equal(t, Span{Position{4, 1, 62}, Position{6, 10, 91}}, table.Pos())
+
+	column := table.Children[2].(*TableRow).Children[1]
+	assert.Equal(t, Span{Position{6, 7, 88}, Position{6, 8, 89}}, column.Pos())
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("broken")
+}
+
+func TestDiagnostics(t *testing.T) {
+	text := `#+begin_src go
+fmt.Println("x")
+:PROPERTIES:
+:ID: 1
+#+end_quote
+<2022-13-01 Mon>`
+
+	d := newDocument()
+	ParseFromText(d, text)
+	assert.Equal(t, []Diagnostic{
+		{SeverityWarning, "unterminated block #+BEGIN_SRC", Position{1, 1, 0}, ""},
+		{SeverityWarning, "unterminated drawer :PROPERTIES:", Position{3, 1, 32}, ""},
+		{SeverityWarning, "#+END_QUOTE without #+BEGIN_QUOTE", Position{5, 1, 52}, ""},
+		{SeverityWarning, "bad timestamp <2022-13-01 Mon>", Position{6, 1, 64}, ""},
+	}, d.Diagnostics)
+	assert.Nil(t, d.Err())
+
+	d = newDocument()
+	Parse(d, io.MultiReader(strings.NewReader("* heading\n"), errReader{}))
+	assert.EqualError(t, d.Err(), "2:1: error: read error: broken")
+
+	d = newDocument()
+	nodes := Parse(d, strings.NewReader("| "+strings.Repeat("a", 1<<17)+" |"))
+	assert.Nil(t, d.Err())
+	assert.Equal(t, TableName, nodes[0].Name())
+
+	d = newDocument()
+	ParseFromText(d, ":word: is text\n:LOGBOOK:\n* heading\n:note:\n** sub\n:END:")
+	assert.Equal(t, []Diagnostic{
+		{SeverityWarning, "unterminated drawer :LOGBOOK:", Position{2, 1, 15}, ""},
+		{SeverityWarning, ":END: without drawer", Position{6, 1, 49}, ""},
+	}, d.Diagnostics)
+
+	// links are resolved after parsing, but sorted by position
+	d = newDocument()
+	ParseFromText(d, "[[#nowhere]]\n<2022-13-01 Mon>")
+	assert.Equal(t, []Diagnostic{
+		{SeverityWarning, `link target "#nowhere" not found`, Position{1, 1, 0}, ""},
+		{SeverityWarning, "bad timestamp <2022-13-01 Mon>", Position{2, 1, 13}, ""},
+	}, d.Diagnostics)
+}
+
+func TestProperties(t *testing.T) {
+	text := `#+PROPERTY: header-args :results silent
+* heading
+  :PROPERTIES:
+  :CUSTOM_ID: custom
+  :Owner:    maple
+  :Tags:     a
+  :TAGS+:    b
+  :END:
+** child
+   :LOGBOOK:
+   - note
+   :END:`
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	v, ok := d.Property("HEADER-ARGS")
+	assert.True(t, ok)
+	assert.Equal(t, ":results silent", v)
+
+	heading := nodes[1].(*Heading)
+	assert.Equal(t, "custom", heading.Id())
+	assert.Equal(t, "a b", heading.Properties.Get("tags"))
+
+	child := heading.Children[0].(*Heading)
+	assert.Nil(t, child.Properties)
+	assert.Equal(t, DrawerName, child.Children[0].Name())
+	assert.Equal(t, ListName, child.Children[0].(*Drawer).Children[0].Name())
+
+	_, ok = child.Property("OWNER", false)
+	assert.False(t, ok)
+	v, ok = child.Property("OWNER", true)
+	assert.True(t, ok)
+	assert.Equal(t, "maple", v)
+
+	_, ok = child.Property("HEADER-ARGS", false)
+	assert.False(t, ok)
+	v, ok = child.Property("header-args", true)
+	assert.True(t, ok)
+	assert.Equal(t, ":results silent", v)
+}
+
+func TestPlanning(t *testing.T) {
+	text := `* DONE heading
+  CLOSED: [2026-10-18 Sun 09:30] SCHEDULED: <2026-10-17 Sat>
+  :PROPERTIES:
+  :CUSTOM_ID: done
+  :END:
+  SCHEDULED: <2026-10-20 Tue>`
+
+	d := newDocument()
+	heading := ParseFromText(d, text)[0].(*Heading)
+
+	assert.Nil(t, heading.Deadline)
+	assert.Equal(t, "done", heading.Id())
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), heading.Scheduled.Time)
+	assert.True(t, heading.Scheduled.Active)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC), heading.Closed.Time)
+	assert.False(t, heading.Closed.Active)
+	assert.Equal(t, Span{Position{2, 11, 25}, Position{2, 33, 47}}, heading.Closed.Pos())
+	// only the line after heading is planning
+	assert.Equal(t, ParagraghName, heading.Children[0].Name())
+}
+
+func TestFixedWidth(t *testing.T) {
+	text := `: line
+  : indented
+:foo:
+* heading
+  :END:`
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	fixed := nodes[0].(*FixedWidth)
+	assert.Equal(t, "line\nindented", fixed.Content)
+	assert.Equal(t, 0, fixed.Level)
+	assert.Equal(t, []int{0, 2}, fixed.Indents)
+	// :foo: is not closed by the :END: in next section
+	assert.Equal(t, ParagraghName, nodes[1].Name())
+	assert.Equal(t, HeadingName, nodes[2].Name())
+}
+
+func TestBlockResult(t *testing.T) {
+	d := newDocument()
+	nodes := ParseFromText(d, `#+RESULTS: foo
+: hello
+text`)
+
+	result := nodes[0].(*BlockResult)
+	assert.Len(t, result.Children, 1)
+	assert.Equal(t, "hello", result.Children[0].(*FixedWidth).Content)
+	assert.Equal(t, ParagraghName, nodes[1].Name())
+	assert.Empty(t, d.Diagnostics)
+
+	d = newDocument()
+	nodes = ParseFromText(d, "#+RESULTS:\n\ntext")
+	assert.Empty(t, nodes[0].(*BlockResult).Children)
+	assert.Equal(t, []Diagnostic{
+		{SeverityWarning, "#+RESULTS: is not followed by a result", Position{1, 1, 0}, ""},
+	}, d.Diagnostics)
+}
+
+func TestSettingsInBlock(t *testing.T) {
+	d := newDocument()
+	d.FS = mapFS{"setup.org": "#+TODO: FOO | BAR"}
+	nodes := ParseFromText(d, `#+begin_example
+#+TODO: FOO | BAR
+#+PRIORITIES: 1 5 3
+#+OPTIONS: H:1 ^:nil toc:nil
+#+SETUPFILE: "setup.org"
+#+end_example
+#+BEGIN_SRC org
+#+TODO: WAIT
+#+END_SRC
+* TODO Real task`)
+
+	assert.Equal(t, "TODO", nodes[2].(*Heading).Keyword)
+	assert.Equal(t, defaultPriorities, d.Priorities())
+	assert.Equal(t, DefaultOptions(), d.Options())
+	assert.Equal(t, "", d.Get("OPTIONS"))
+	for _, keyword := range []string{"FOO", "WAIT"} {
+		_, ok := d.TodoKeyword(keyword)
+		assert.False(t, ok)
+	}
+	assert.Empty(t, d.Diagnostics)
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"regexp"
+	"strings"
 )
 
 const (
@@ -15,6 +16,8 @@ var (
 )
 
 type Drawer struct {
+	Span
+
 	Type       string
 	Level      int
 	Properties map[string]string
@@ -25,27 +28,80 @@ func (Drawer) Name() string {
 	return DrawerName
 }
 
+// Get returns the value of a property, key is case insensitive
 func (s *Drawer) Get(key string) string {
-	return s.Properties[key]
+	return s.Properties[strings.ToUpper(key)]
+}
+
+func (s *Drawer) Lookup(key string) (string, bool) {
+	v, ok := s.Properties[strings.ToUpper(key)]
+	return v, ok
+}
+
+// setProperty sets the value of key, "KEY+" appends the value to the old one
+func setProperty(props map[string]string, key, value string) {
+	key = strings.ToUpper(key)
+	if strings.HasSuffix(key, "+") {
+		key = key[:len(key)-1]
+		if old, ok := props[key]; ok && old != "" {
+			value = old + " " + value
+		}
+	}
+	props[key] = value
+}
+
+func (s *parser) parseProperties(d *Document, lines []string) map[string]string {
+	line, column := s.line, s.column
+
+	props := make(map[string]string)
+	for i := range lines {
+		if isBlankline(lines[i]) {
+			continue
+		}
+		m := propertyRegexp.FindStringSubmatch(lines[i])
+		if m == nil {
+			s.seekLine(line, column, i)
+			s.warn(d, s.line, s.column, "bad property %s", strings.TrimSpace(lines[i]))
+			continue
+		}
+		setProperty(props, m[2], strings.TrimSpace(m[4]))
+	}
+	return props
 }
 
 func (s *parser) ParseDrawer(d *Document, lines []string) (*Drawer, int) {
 	match := beginDrawerRegexp.FindStringSubmatch(lines[0])
-	if match == nil {
+	if match == nil || strings.ToUpper(match[2]) == "END" {
 		return nil, 0
 	}
 
 	idx, end := 1, len(lines)
 	for idx < end {
+		// drawer can't contain a heading, so :END: of another
+		// section won't close it
+		if headingRegexp.MatchString(lines[idx]) {
+			break
+		}
 		if m := endDrawerRegexp.FindStringSubmatch(lines[idx]); m != nil {
-			return &Drawer{
-				Type:       match[2],
-				Level:      len(match[1]),
-				Properties: make(map[string]string),
-				Children:   s.ParseAll(d, lines[1:idx], false),
-			}, idx + 1
+			b := &Drawer{
+				Type:  match[2],
+				Level: len(match[1]),
+			}
+			s.seekLine(s.line, s.column, 1)
+			if strings.ToUpper(b.Type) == "PROPERTIES" {
+				b.Properties = s.parseProperties(d, lines[1:idx])
+			} else {
+				b.Properties = make(map[string]string)
+				b.Children = s.ParseAll(d, lines[1:idx], false)
+			}
+			return b, idx + 1
 		}
 		idx++
 	}
+	// the line like :word: is plain text, :END: of the same section would
+	// have closed it, so only a known drawer is reported
+	if name := strings.ToUpper(match[2]); name == "PROPERTIES" || name == "LOGBOOK" {
+		s.warn(d, s.line, s.column, "unterminated drawer :%s:", match[2])
+	}
 	return nil, 0
 }
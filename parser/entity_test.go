@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntity(t *testing.T) {
+	d := newDocument()
+	nodes := ParseFromText(d, `\alpha{}b \sup1 \supset`)
+
+	children := nodes[0].(*Paragragh).Children
+	alpha := children[0].(*Entity)
+	assert.True(t, alpha.Brackets)
+	assert.Equal(t, "α", alpha.Def.UTF8)
+	assert.Equal(t, Span{Position{1, 1, 0}, Position{1, 9, 8}}, alpha.Pos())
+	assert.Equal(t, "sup1", children[2].(*Entity).Def.Name)
+	assert.Equal(t, `\supset`, children[4].(*Entity).Def.Latex)
+}
+
+func TestScript(t *testing.T) {
+	names := func(text string) []string {
+		d := newDocument()
+		nodes := ParseFromText(d, text)
+		var names []string
+		for _, child := range nodes[len(nodes)-1].(*Paragragh).Children {
+			names = append(names, child.Name())
+		}
+		return names
+	}
+	assert.Equal(t, []string{InlineTextName, SubscriptName, InlineTextName, SuperscriptName}, names("a_1 b^{2}"))
+	assert.Equal(t, []string{InlineTextName, SuperscriptName}, names("#+OPTIONS: ^:{}\na_1 b^{2}"))
+	assert.Equal(t, []string{InlineTextName}, names("#+OPTIONS: toc:nil ^:nil\na_1 b^{2}"))
+}
@@ -4,14 +4,15 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"unicode"
 )
 
 const HeadingName = "Heading"
 
 var (
 	headingRegexp      = regexp.MustCompile(`^(\*+)\s+(.*?)(?:\r?\n|$)`)
-	headingTitleRegexp = regexp.MustCompile(`^(?:\[#([A-C])\])?\s*(.+?)(?:\s+:(.+?):)?$`)
+	headingTitleRegexp = regexp.MustCompile(`^(?:\[#([A-Z]|[0-9]+)\])?\s*(.+?)(?:\s+:(.+?):)?$`)
+	planningRegexp     = regexp.MustCompile(`^\s*(?:SCHEDULED|DEADLINE|CLOSED):`)
+	planningItemRegexp = regexp.MustCompile(`(SCHEDULED|DEADLINE|CLOSED):\s*`)
 )
 
 type Section struct {
@@ -38,6 +39,7 @@ func (s *Section) add(node *Heading) string {
 		}
 	}
 	sec := &Section{Heading: node, parent: parent}
+	node.section = sec
 	parent.Children = append(parent.Children, sec)
 	if parent.Heading == nil {
 		sec.idx = fmt.Sprintf("%d", len(parent.Children))
@@ -50,20 +52,48 @@ func (s *Section) add(node *Heading) string {
 
 // STARS KEYWORD PRIORITY TITLE TAGS
 type Heading struct {
+	Span
+
 	Index      string
 	Stars      int
 	Keyword    string
 	Priority   string
+	Commented  bool
 	Title      []Node
 	Tags       []string
+	Scheduled  *InlineTimestamp
+	Deadline   *InlineTimestamp
+	Closed     *InlineTimestamp
 	Properties *Drawer
 	Children   []Node
+
+	done            bool
+	noExport        bool
+	defaultPriority string
+	section         *Section
+	document        *Document
 }
 
 func (Heading) Name() string {
 	return HeadingName
 }
 
+// IsDone reports whether the keyword of heading is a done state
+func (s *Heading) IsDone() bool {
+	return s.done
+}
+
+// IsExported reports whether the heading is exported, see #+EXCLUDE_TAGS,
+// #+SELECT_TAGS and "tasks" of #+OPTIONS
+func (s *Heading) IsExported() bool {
+	return !s.noExport
+}
+
+// HasPlanning reports whether the heading has any planning timestamp
+func (s *Heading) HasPlanning() bool {
+	return s.Scheduled != nil || s.Deadline != nil || s.Closed != nil
+}
+
 func (s *Heading) Id() string {
 	if s.Properties != nil {
 		if id := s.Properties.Get("CUSTOM_ID"); id != "" {
@@ -73,33 +103,94 @@ func (s *Heading) Id() string {
 	return fmt.Sprintf("heading-%s", s.Index)
 }
 
+// Property returns the value of key in the property drawer of the heading,
+// if inherit is true and the heading has no such property, the value is
+// looked up in its ancestors and then #+PROPERTY of the document.
+func (s *Heading) Property(key string, inherit bool) (string, bool) {
+	if s.Properties != nil {
+		if v, ok := s.Properties.Lookup(key); ok {
+			return v, true
+		}
+	}
+	if !inherit {
+		return "", false
+	}
+	if s.section != nil {
+		if parent := s.section.parent; parent != nil && parent.Heading != nil {
+			return parent.Heading.Property(key, inherit)
+		}
+	}
+	if s.document != nil {
+		return s.document.Property(key)
+	}
+	return "", false
+}
+
+// parsePlanning parses the planning line, which is the line just after heading
+func (s *parser) parsePlanning(d *Document, b *Heading, text string) {
+	line, column := s.line, s.column
+	for _, m := range planningItemRegexp.FindAllStringSubmatchIndex(text, -1) {
+		if m[1] >= len(text) {
+			continue
+		}
+		s.seek(line, column+m[1])
+		ts, n := s.ParseInlineTimestamp(d, text, m[1])
+		if ts == nil {
+			continue
+		}
+		s.setPos(ts, line, column+m[1], text[m[1]:m[1]+n])
+		switch text[m[2]:m[3]] {
+		case "SCHEDULED":
+			b.Scheduled = ts
+		case "DEADLINE":
+			b.Deadline = ts
+		case "CLOSED":
+			b.Closed = ts
+		}
+	}
+}
+
 func (s *parser) ParseHeading(d *Document, lines []string) (*Heading, int) {
-	match := headingRegexp.FindStringSubmatch(lines[0])
+	match := headingRegexp.FindStringSubmatchIndex(lines[0])
 	if len(match) == 0 {
 		return nil, 0
 	}
-	title := match[2]
-	keyword := ""
+	line, column := s.line, s.column
+
+	title, offset := lines[0][match[4]:match[5]], match[4]
+	b := &Heading{Stars: match[3] - match[2], document: d}
 	if v := strings.SplitN(title, " ", 2); len(v) >= 2 {
-		todo := strings.FieldsFunc(d.Get("TODO"), func(r rune) bool { return unicode.IsSpace(r) || r == '|' })
-		for i := range todo {
-			if v[0] == todo[i] {
-				keyword = v[0]
-				title = v[1]
-				break
-			}
+		if todo, ok := d.TodoKeyword(v[0]); ok {
+			b.Keyword, b.done = todo.Name, todo.Done
+			title = v[1]
+			offset = offset + len(v[0]) + 1
 		}
 	}
-	b := &Heading{
-		Stars:   len(match[1]),
-		Keyword: keyword,
-	}
 	b.Index = d.Sections.add(b)
 
-	tmatch := headingTitleRegexp.FindStringSubmatch(title)
-	b.Priority = tmatch[1]
-	b.Title = s.ParseAllInline(d, tmatch[2], false)
-	b.Tags = strings.FieldsFunc(tmatch[3], func(r rune) bool { return r == ':' })
+	if tmatch := headingTitleRegexp.FindStringSubmatchIndex(title); tmatch != nil {
+		priorities := d.Priorities()
+		b.defaultPriority = priorities.Default
+		if tmatch[2] >= 0 {
+			b.Priority = title[tmatch[2]:tmatch[3]]
+			if !priorities.Contains(b.Priority) {
+				s.warn(d, line, column+offset+tmatch[2]-2, "priority [#%s] is out of range %s-%s", b.Priority, priorities.Highest, priorities.Lowest)
+			}
+		}
+		if tmatch[6] >= 0 {
+			b.Tags = strings.FieldsFunc(title[tmatch[6]:tmatch[7]], func(r rune) bool { return r == ':' })
+		}
+		start := tmatch[4]
+		// COMMENT keyword makes the subtree commented
+		if t := title[start:tmatch[5]]; t == "COMMENT" || strings.HasPrefix(t, "COMMENT ") {
+			b.Commented = true
+			start = start + len(t) - len(strings.TrimLeft(t[len("COMMENT"):], " "))
+		}
+		if start < tmatch[5] {
+			s.seek(line, column+offset+start)
+			b.Title = s.ParseAllInline(d, title[start:tmatch[5]], false)
+		}
+	}
 
 	idx, end := 1, len(lines)
 	for idx < end {
@@ -108,10 +199,19 @@ func (s *parser) ParseHeading(d *Document, lines []string) (*Heading, int) {
 		}
 		idx++
 	}
-	children := s.ParseAll(d, lines[1:idx], false)
+	start := 1
+	if idx > 1 && planningRegexp.MatchString(lines[1]) {
+		s.seekLine(line, column, 1)
+		s.parsePlanning(d, b, lines[1])
+		start = 2
+	}
+	s.seekLine(line, column, start)
+	children := s.ParseAll(d, lines[start:idx], false)
 	if len(children) > 0 && children[0].Name() == DrawerName {
-		b.Properties = children[0].(*Drawer)
-		children = children[1:]
+		if drawer := children[0].(*Drawer); strings.ToUpper(drawer.Type) == "PROPERTIES" {
+			b.Properties = drawer
+			children = children[1:]
+		}
 	}
 	b.Children = children
 
@@ -0,0 +1,375 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const IncludeName = "Include"
+
+var errOutsideDir = errors.New("file is outside of the directory")
+
+var (
+	includeRegexp       = regexp.MustCompile(`(?i)^(\s*)#\+(INCLUDE):\s+(.*)$`)
+	includeFileRegexp   = regexp.MustCompile(`^(?:"([^"]+)"|(\S+))\s*(.*)$`)
+	includeCustomRegexp = regexp.MustCompile(`(?i)^\s*:CUSTOM_ID:\s*(\S+)\s*$`)
+	includeEscapeRegexp = regexp.MustCompile(`^([ \t]*)(,?(?:\*|#\+))`)
+)
+
+// FileSystem reads the files of #+INCLUDE and #+SETUPFILE, name is
+// slash separated and relative to the root of the file system.
+type FileSystem interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// Dir is a FileSystem of the files in the directory, name is relative to
+// the directory even if it starts with "/", and reading the files outside
+// the directory, such as "../file.org", is an error.
+type Dir string
+
+func (dir Dir) ReadFile(name string) ([]byte, error) {
+	file := path.Clean(strings.TrimLeft(name, "/"))
+	if file == ".." || strings.HasPrefix(file, "../") {
+		return nil, &os.PathError{Op: "read", Path: name, Err: errOutsideDir}
+	}
+	return ioutil.ReadFile(filepath.Join(string(dir), filepath.FromSlash(file)))
+}
+
+// Include is #+INCLUDE: "file" [src LANG|example] [:minlevel N] [:lines "A-B"],
+// Children is the included content, which is parsed as a part of the
+// document, the positions of Children refer to File.
+type Include struct {
+	Span
+
+	Key      string
+	Value    string
+	File     string
+	Children []Node
+
+	// byte offset of each included line
+	offsets []int
+}
+
+func (Include) Name() string {
+	return IncludeName
+}
+
+// lineOffsets returns the byte offset of each line
+func lineOffsets(lines []string) []int {
+	offsets, offset := make([]int, len(lines)), 0
+	for i, line := range lines {
+		offsets[i] = offset
+		offset = offset + len(line) + 1
+	}
+	return offsets
+}
+
+// currentFile returns the included file which is being parsed, or empty
+// for the document itself
+func (d *Document) currentFile() string {
+	if len(d.includes) == 0 {
+		return ""
+	}
+	return d.includes[len(d.includes)-1]
+}
+
+// inInclude calls fn with the file and line offsets of n, so that the
+// positions and diagnostics of the included nodes refer to the included
+// file
+func (s *parser) inInclude(d *Document, n *Include, fn func()) {
+	offsets := s.offsets
+	s.offsets = n.offsets
+	d.includes = append(d.includes, n.File)
+	fn()
+	d.includes = d.includes[:len(d.includes)-1]
+	s.offsets = offsets
+}
+
+// walk is Walk, but the included nodes are traversed with inInclude
+func (s *parser) walk(d *Document, nodes []Node, fn func(Node) bool) {
+	Walk(nodes, func(node Node) bool {
+		n, ok := node.(*Include)
+		if !ok {
+			return fn(node)
+		}
+		if fn(node) {
+			s.inInclude(d, n, func() { s.walk(d, n.Children, fn) })
+		}
+		return false
+	})
+}
+
+// resolvePath returns the path of name which is relative to the file
+// including it
+func (d *Document) resolvePath(name string) string {
+	if len(d.includes) == 0 || path.IsAbs(name) {
+		return path.Clean(name)
+	}
+	return path.Join(path.Dir(d.includes[len(d.includes)-1]), name)
+}
+
+// readFile reads name with the file system of the document, the cycle
+// of includes is reported as an error.
+func (d *Document) readFile(name string) ([]string, error) {
+	for _, file := range d.includes {
+		if file == name {
+			return nil, &cycleError{files: append(d.includes, name)}
+		}
+	}
+	buf, err := d.FS.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSuffix(strings.Replace(string(buf), "\r\n", "\n", -1), "\n")
+	return strings.Split(text, "\n"), nil
+}
+
+type cycleError struct {
+	files []string
+}
+
+func (e *cycleError) Error() string {
+	return "include cycle " + strings.Join(e.files, " -> ")
+}
+
+// selectLines returns lines in the range of :lines "A-B", A is 1-based
+// and B is excluded like org-mode
+func selectLines(lines []string, rng string) []string {
+	v := strings.SplitN(rng, "-", 2)
+	start, end := 1, len(lines)+1
+	if n, err := strconv.Atoi(strings.TrimSpace(v[0])); err == nil && n > 0 {
+		start = n
+	}
+	if len(v) == 2 {
+		if n, err := strconv.Atoi(strings.TrimSpace(v[1])); err == nil && n < end {
+			end = n
+		}
+	}
+	if start > len(lines) || start >= end {
+		return nil
+	}
+	return lines[start-1 : end-1]
+}
+
+// headingTitle returns the title of heading text without the TODO
+// keyword, priority, COMMENT and tags
+func (d *Document) headingTitle(text string) string {
+	if v := strings.SplitN(text, " ", 2); len(v) == 2 {
+		if _, ok := d.TodoKeyword(v[0]); ok {
+			text = v[1]
+		}
+	}
+	if match := headingTitleRegexp.FindStringSubmatch(text); match != nil {
+		text = match[2]
+	}
+	if text == "COMMENT" {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(text, "COMMENT "))
+}
+
+// selectSubtree returns the subtree of "*heading" or "#custom-id"
+func (d *Document) selectSubtree(lines []string, search string) []string {
+	start, stars := -1, 0
+	for i, line := range lines {
+		match := headingRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if start >= 0 {
+			if len(match[1]) <= stars {
+				return lines[start:i]
+			}
+			continue
+		}
+		found := false
+		switch {
+		case strings.HasPrefix(search, "*"):
+			found = d.headingTitle(match[2]) == strings.TrimSpace(search[1:])
+		case strings.HasPrefix(search, "#"):
+			for _, next := range lines[i+1:] {
+				if headingRegexp.MatchString(next) {
+					break
+				}
+				if m := includeCustomRegexp.FindStringSubmatch(next); m != nil && m[1] == search[1:] {
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			start, stars = i, len(match[1])
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+	return lines[start:]
+}
+
+// shiftHeadings changes the level of headings, so that the top level of
+// lines is minlevel
+func shiftHeadings(lines []string, minlevel int) []string {
+	top := 0
+	for _, line := range lines {
+		if match := headingRegexp.FindStringSubmatch(line); match != nil && (top == 0 || len(match[1]) < top) {
+			top = len(match[1])
+		}
+	}
+	if top == 0 || top == minlevel {
+		return lines
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		if match := headingRegexp.FindStringSubmatch(line); match != nil {
+			stars := len(match[1]) - top + minlevel
+			if stars < 1 {
+				stars = 1
+			}
+			line = strings.Repeat("*", stars) + line[len(match[1]):]
+		}
+		result[i] = line
+	}
+	return result
+}
+
+// readInclude reads the content of #+INCLUDE: value, which is selected by
+// "::search" and :lines, and wrapped in a block if the block type is given
+func (d *Document) readInclude(value string) (string, []string, error) {
+	fmatch := includeFileRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if fmatch == nil {
+		return "", nil, errors.New("missing file of #+INCLUDE")
+	}
+	name := fmatch[1] + fmatch[2]
+	search := ""
+	if i := strings.Index(name, "::"); i >= 0 {
+		name, search = name[:i], name[i+2:]
+	}
+	file := d.resolvePath(name)
+
+	content, err := d.readFile(file)
+	if err != nil {
+		if _, ok := err.(*cycleError); ok {
+			return file, nil, err
+		}
+		return file, nil, fmt.Errorf("could not include %q: %s", name, err)
+	}
+
+	params, attrs := fmatch[3], parseAttributes(fmatch[3])
+	if i := strings.Index(params, ":"); i >= 0 {
+		params = params[:i]
+	}
+	if search != "" {
+		if content = d.selectSubtree(content, search); content == nil {
+			return file, nil, fmt.Errorf("could not find %q in %q", search, name)
+		}
+	}
+	if rng, ok := attrs["lines"]; ok {
+		content = selectLines(content, rng)
+	}
+	if fields := strings.Fields(params); len(fields) > 0 {
+		// wrap the content with a block, such as src go or example
+		blockType := strings.ToUpper(fields[0])
+		begin := "#+BEGIN_" + blockType
+		if len(fields) > 1 {
+			begin = begin + " " + strings.Join(fields[1:], " ")
+		}
+		wrapped := make([]string, 0, len(content)+2)
+		wrapped = append(wrapped, begin)
+		for _, line := range content {
+			// escape the lines like heading or #+END_SRC, which are
+			// unescaped when the block is parsed
+			if blockType == "SRC" || blockType == "EXAMPLE" {
+				line = includeEscapeRegexp.ReplaceAllString(line, "$1,$2")
+			}
+			wrapped = append(wrapped, line)
+		}
+		content = append(wrapped, "#+END_"+blockType)
+	} else if minlevel, err := strconv.Atoi(attrs["minlevel"]); err == nil && minlevel > 0 {
+		content = shiftHeadings(content, minlevel)
+	}
+	return file, content, nil
+}
+
+func (s *parser) ParseInclude(d *Document, lines []string) (*Include, int) {
+	if d.FS == nil {
+		return nil, 0
+	}
+	match := includeRegexp.FindStringSubmatch(lines[0])
+	if match == nil || !includeFileRegexp.MatchString(strings.TrimSpace(match[3])) {
+		return nil, 0
+	}
+	file, content, err := d.readInclude(match[3])
+	b := &Include{Key: match[2], Value: match[3], File: file}
+	if err != nil {
+		s.warn(d, s.line, s.column, "%s", err)
+		return b, 1
+	}
+
+	b.offsets = lineOffsets(content)
+	p := &parser{offsets: b.offsets}
+	p.inInclude(d, b, func() { b.Children = p.ParseAll(d, content, false) })
+	return b, 1
+}
+
+// parseIncludeSettings collects the settings of the included file like
+// parseSettings, since the included content is a part of the document,
+// the errors are reported by ParseInclude.
+func (s *parser) parseIncludeSettings(d *Document, value string) {
+	if d.FS == nil {
+		return
+	}
+	file, content, err := d.readInclude(value)
+	if err != nil {
+		return
+	}
+	p := &parser{offsets: lineOffsets(content)}
+	d.includes = append(d.includes, file)
+	p.parseSettings(d, content)
+	d.includes = d.includes[:len(d.includes)-1]
+}
+
+// parseSetupFile merges the in-buffer settings of #+SETUPFILE: "file" into
+// the document, which are keywords, macros, links, options and properties,
+// the other content of the file is ignored.
+func (s *parser) parseSetupFile(d *Document, line int, value string) {
+	name := strings.Trim(strings.TrimSpace(value), `"`)
+	if d.FS == nil || name == "" {
+		return
+	}
+	file := d.resolvePath(name)
+
+	lines, err := d.readFile(file)
+	if err != nil {
+		if _, ok := err.(*cycleError); ok {
+			s.warn(d, line, 0, "%s", err)
+		} else {
+			s.warn(d, line, 0, "could not read setup file %q: %s", name, err)
+		}
+		return
+	}
+
+	p := &parser{offsets: lineOffsets(lines)}
+	d.includes = append(d.includes, file)
+	p.parseSettings(d, lines)
+	for _, i := range outsideBlocks(lines) {
+		line := lines[i]
+		match := keywordRegexp.FindStringSubmatch(line)
+		if match == nil || affiliatedRegexp.MatchString(line) || exportKeywordRegexp.MatchString(line) {
+			continue
+		}
+		switch strings.ToUpper(match[2]) {
+		case "INCLUDE", "SETUPFILE":
+		default:
+			d.setKeyword(match[2], match[4])
+		}
+	}
+	d.includes = d.includes[:len(d.includes)-1]
+}
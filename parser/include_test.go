@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapFS map[string]string
+
+func (fs mapFS) ReadFile(name string) ([]byte, error) {
+	if text, ok := fs[name]; ok {
+		return []byte(text), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func TestInclude(t *testing.T) {
+	fs := mapFS{
+		"chapter.org": "* Chapter\n** Other Section\n** TODO [#A] Section :tag:\ncontent\n:PROPERTIES:\n:CUSTOM_ID: custom\n:END:\n** Other\nother\n",
+		"main.go":     "package main\n\nfunc main() {\n}\n\n// #+END_SRC\n",
+		"sub/a.org":   "#+INCLUDE: \"b.org\"\n",
+		"sub/b.org":   "b content\n",
+		"cycle.org":   "#+INCLUDE: \"cycle.org\"\n",
+	}
+	include := func(text string) (*Include, *Document) {
+		d := newDocument()
+		d.FS = fs
+		nodes := ParseFromText(d, text)
+		assert.Len(t, nodes, 1)
+		return nodes[0].(*Include), d
+	}
+	headings := func(nodes []Node) []int {
+		stars := make([]int, 0)
+		Walk(nodes, func(node Node) bool {
+			if heading, ok := node.(*Heading); ok {
+				stars = append(stars, heading.Stars)
+			}
+			return true
+		})
+		return stars
+	}
+
+	n, d := include(`#+INCLUDE: "chapter.org" :minlevel 2`)
+	assert.Equal(t, "chapter.org", n.File)
+	assert.Equal(t, []int{2, 3, 3, 3}, headings(n.Children))
+	assert.Empty(t, d.Diagnostics)
+
+	n, _ = include(`#+INCLUDE: "main.go" src go :lines "3-5"`)
+	block := n.Children[0].(*Block)
+	assert.Equal(t, "SRC", block.Type)
+	assert.Equal(t, []string{"go"}, block.Parameters)
+	assert.Equal(t, "func main() {\n}", block.Children[0].(*InlineText).Content)
+
+	n, _ = include(`#+INCLUDE: "main.go" src go :lines "6-"`)
+	assert.Len(t, n.Children, 1)
+	assert.Equal(t, "// #+END_SRC", n.Children[0].(*Block).Children[0].(*InlineText).Content)
+
+	n, _ = include(`#+INCLUDE: "chapter.org::*Section"`)
+	assert.Equal(t, []int{2}, headings(n.Children))
+	assert.Equal(t, "TODO", n.Children[0].(*Heading).Keyword)
+
+	n, _ = include(`#+INCLUDE: "chapter.org::#custom" :minlevel 1`)
+	assert.Equal(t, []int{1}, headings(n.Children))
+
+	n, d = include(`#+INCLUDE: "sub/a.org"`)
+	assert.Equal(t, "sub/b.org", n.Children[0].(*Include).File)
+	assert.Empty(t, d.Diagnostics)
+
+	n, d = include(`#+INCLUDE: "cycle.org"`)
+	assert.Equal(t, "cycle.org", n.Children[0].(*Include).File)
+	assert.Len(t, d.Diagnostics, 1)
+	assert.Equal(t, "cycle.org:1:1: warning: include cycle cycle.org -> cycle.org", d.Diagnostics[0].Error())
+
+	_, d = include(`#+INCLUDE: "missing.org"`)
+	assert.Len(t, d.Diagnostics, 1)
+}
+
+func TestIncludeSettings(t *testing.T) {
+	d := newDocument()
+	d.FS = mapFS{
+		"settings.org": "#+TODO: FOO | BAR\n#+PRIORITIES: 1 5 3\n#+INCLUDE: \"options.org\"\n* FOO inc2",
+		"options.org":  "#+OPTIONS: num:t",
+		"example.org":  "#+OPTIONS: toc:nil",
+	}
+	nodes := ParseFromText(d, `* FOO before
+#+INCLUDE: "settings.org"
+#+INCLUDE: "example.org" example`)
+
+	assert.Equal(t, "FOO", nodes[0].(*Heading).Keyword)
+	include := nodes[0].(*Heading).Children[0].(*Include)
+	heading := include.Children[3].(*Heading)
+	assert.Equal(t, "FOO", heading.Keyword)
+	assert.Equal(t, "inc2", nodesText(heading.Title))
+	assert.Equal(t, "1", d.Priorities().Highest)
+	assert.Equal(t, "num:t", d.Get("OPTIONS"))
+	assert.Empty(t, d.Diagnostics)
+}
+
+func TestIncludePosition(t *testing.T) {
+	d := newDocument()
+	d.FS = mapFS{"c.org": "* B\n#+INCLUDE: \"missing.org\"\n[[#x]] {{{nope}}}"}
+	nodes := ParseFromText(d, `* A
+text
+#+INCLUDE: "c.org"
+end`)
+
+	include := nodes[0].(*Heading).Children[1].(*Include)
+	assert.Equal(t, Span{Position{3, 1, 9}, Position{3, 19, 27}}, include.Pos())
+	heading := include.Children[0].(*Heading)
+	assert.Equal(t, Span{Position{1, 1, 0}, Position{3, 18, 46}}, heading.Pos())
+	link := heading.Children[1].(*Paragragh).Children[0].(*InlineLink)
+	assert.Equal(t, Span{Position{3, 1, 29}, Position{3, 7, 35}}, link.Pos())
+
+	errs := make([]string, 0)
+	for _, diag := range d.Diagnostics {
+		errs = append(errs, diag.Error())
+	}
+	assert.Equal(t, []string{
+		`c.org:2:1: warning: could not include "missing.org": file does not exist`,
+		`c.org:3:1: warning: link target "#x" not found`,
+		`c.org:3:8: warning: undefined macro {{{nope}}}`,
+	}, errs)
+}
+
+func TestSetupFile(t *testing.T) {
+	d := newDocument()
+	d.FS = mapFS{
+		"setup/theme.setup": `#+TITLE: Setup
+#+AUTHOR: honmaple
+#+TODO: TODO WAIT | DONE
+#+OPTIONS: toc:nil
+#+MACRO: greet Hello $1
+#+LINK: gh https://github.com/%s
+#+PROPERTY: header-args :results output
+#+SETUPFILE: common.setup
+#+HTML: <br>
+* Heading
+#+NAME: ignored
+#+begin_src org
+#+AUTHOR: Source
+#+MACRO: greet Bye $1
+#+LINK: gh https://gitlab.com/%s
+#+end_src`,
+		"setup/common.setup": "#+PRIORITIES: 1 5 3\n#+SETUPFILE: theme.setup",
+	}
+	nodes := ParseFromText(d, `#+TITLE: Document
+#+SETUPFILE: "setup/theme.setup"
+#+OPTIONS: num:t
+* WAIT {{{greet(world)}}} [[gh:honmaple]]`)
+
+	assert.Equal(t, "Document", d.Get("TITLE"))
+	assert.Equal(t, "honmaple", d.Get("AUTHOR"))
+	assert.Equal(t, "", d.Get("HTML"))
+	assert.Equal(t, "", d.Get("NAME"))
+	assert.Equal(t, "toc:nil num:t", d.Get("OPTIONS"))
+	assert.Equal(t, Priorities{Highest: "1", Lowest: "5", Default: "3"}, d.Priorities())
+	assert.Equal(t, ":results output", d.Properties["HEADER-ARGS"])
+
+	heading := nodes[3].(*Heading)
+	assert.Equal(t, "WAIT", heading.Keyword)
+	assert.Equal(t, "Hello world", nodesText(heading.Title))
+	assert.Len(t, nodes, 4)
+
+	link := heading.Title[len(heading.Title)-1].(*InlineLink)
+	assert.Equal(t, "https", link.Protocol)
+	assert.Equal(t, "github.com/honmaple", link.URL)
+
+	assert.Len(t, d.Diagnostics, 1)
+	assert.Equal(t, "setup/common.setup:2:1: warning: include cycle setup/theme.setup -> setup/common.setup -> setup/theme.setup", d.Diagnostics[0].Error())
+}
+
+func TestDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "org")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "a.org"), []byte("* A\n"), 0644); err != nil {
+		panic(err)
+	}
+
+	dir := Dir(filepath.Join(root, "sub"))
+	_, err = dir.ReadFile("../a.org")
+	assert.EqualError(t, err, "read ../a.org: file is outside of the directory")
+	_, err = dir.ReadFile("/../a.org")
+	assert.Error(t, err)
+
+	dir = Dir(root)
+	for _, name := range []string{"a.org", "/a.org", "sub/../a.org"} {
+		buf, err := dir.ReadFile(name)
+		assert.NoError(t, err)
+		assert.Equal(t, "* A\n", string(buf))
+	}
+
+	d := newDocument()
+	d.FS = dir
+	nodes := ParseFromText(d, `#+INCLUDE: "a.org"`)
+	assert.Equal(t, "A", nodesText(nodes[0].(*Include).Children[0].(*Heading).Title))
+}
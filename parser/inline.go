@@ -1,12 +1,10 @@
 package parser
 
 import (
-	"fmt"
 	"mime"
 	"path/filepath"
 	"regexp"
 	"strings"
-	"time"
 	"unicode"
 )
 
@@ -26,19 +24,27 @@ const (
 	InlineLineBreakName = "LineBreak"
 	InlineBackSlashName = "BackSlash"
 	InlineTimestampName = "Timestamp"
+	SubscriptName       = "Subscript"
+	SuperscriptName     = "Superscript"
+	InlineSrcName       = "InlineSrc"
+	InlineCallName      = "InlineCall"
+	ExportSnippetName   = "ExportSnippet"
 )
 
 var (
-	plainLinkRegexp     = regexp.MustCompile(`^(\w+)://`)
+	plainLinkRegexp     = regexp.MustCompile(`^([a-zA-Z][\w+-]*):(?://)?`)
 	angleLinkRegexp     = regexp.MustCompile(`^<(\w+):(.+)>`)
 	regularLinkRegexp   = regexp.MustCompile(`^\[\[(.+?)\](?:\[(.+?)\])?\]`)
-	commentRegexp       = regexp.MustCompile(`^(\s*)#(.*)$`)
-	percentRegexp       = regexp.MustCompile(`^\[(\d+/\d+|\d+%)\]`)
+	percentRegexp       = regexp.MustCompile(`^\[(\d*/\d*|\d*%)\]`)
 	footnoteReferRegexp = regexp.MustCompile(`^\[fn:([\w-]*?)(:(.*?))?\]`)
-	timestampRegexp     = regexp.MustCompile(`^<(\d{4}-\d{2}-\d{2})( [A-Za-z]+)?( \d{2}:\d{2})?( \+\d+[dwmy])?>`)
+	exportSnippetRegexp = regexp.MustCompile(`(?s)^@@([-a-zA-Z0-9]+):(.*?)@@`)
+	inlineSrcRegexp     = regexp.MustCompile(`^src_([^\s\[\]{}]+)(?:\[([^\]\n]*)\])?\{`)
+	inlineCallRegexp    = regexp.MustCompile(`^call_([^\s\[\]()]+)(?:\[([^\]\n]*)\])?\(([^)\n]*)\)(?:\[([^\]\n]*)\])?`)
 )
 
 type InlineText struct {
+	Span
+
 	Raw     bool
 	Content string
 }
@@ -48,9 +54,14 @@ func (InlineText) Name() string {
 }
 
 type InlineLink struct {
+	Span
+
 	URL      string
 	Desc     string
 	Protocol string
+	// Anchor is the heading or target of an internal link, which is set
+	// after the document is parsed
+	Anchor Anchor
 }
 
 func (InlineLink) Name() string {
@@ -72,6 +83,8 @@ func (s *InlineLink) Type() LinkType {
 }
 
 type InlineEmphasis struct {
+	Span
+
 	Marker   string
 	Children []Node
 }
@@ -81,6 +94,8 @@ func (InlineEmphasis) Name() string {
 }
 
 type InlinePercent struct {
+	Span
+
 	Num string
 }
 
@@ -89,6 +104,8 @@ func (InlinePercent) Name() string {
 }
 
 type InlineLineBreak struct {
+	Span
+
 	Count int
 }
 
@@ -97,6 +114,8 @@ func (InlineLineBreak) Name() string {
 }
 
 type InlineBackSlash struct {
+	Span
+
 	Count int
 	Break bool
 }
@@ -105,14 +124,73 @@ func (InlineBackSlash) Name() string {
 	return InlineBackSlashName
 }
 
-type InlineTimestamp struct {
-	Time     time.Time
-	IsDate   bool
-	Interval string
+// InlineSrc is src_LANG[HEADERS]{BODY}, Parameters is the raw text of
+// HEADERS and Headers is parsed from it.
+type InlineSrc struct {
+	Span
+
+	Lang       string
+	Parameters string
+	Headers    map[string]string
+	Content    string
+}
+
+func (InlineSrc) Name() string {
+	return InlineSrcName
+}
+
+// InlineCall is call_NAME[INSIDE-HEADERS](ARGS)[END-HEADERS],
+// InsideHeaders and EndHeaders are the raw text of headers, which are
+// parsed into InsideHeaderArgs and EndHeaderArgs.
+type InlineCall struct {
+	Span
+
+	Call             string
+	InsideHeaders    string
+	InsideHeaderArgs map[string]string
+	Args             string
+	EndHeaders       string
+	EndHeaderArgs    map[string]string
+}
+
+func (InlineCall) Name() string {
+	return InlineCallName
+}
+
+// ExportSnippet is @@backend:value@@, Backend is in lower case
+type ExportSnippet struct {
+	Span
+
+	Backend string
+	Value   string
 }
 
-func (InlineTimestamp) Name() string {
-	return InlineTimestampName
+func (ExportSnippet) Name() string {
+	return ExportSnippetName
+}
+
+// Subscript is a_b or a_{b}
+type Subscript struct {
+	Span
+
+	Brackets bool
+	Children []Node
+}
+
+func (Subscript) Name() string {
+	return SubscriptName
+}
+
+// Superscript is a^b or a^{b}
+type Superscript struct {
+	Span
+
+	Brackets bool
+	Children []Node
+}
+
+func (Superscript) Name() string {
+	return SuperscriptName
 }
 
 func isSpace(line string, index int) bool {
@@ -122,6 +200,11 @@ func isSpace(line string, index int) bool {
 	return line[index] == ' '
 }
 
+func isWordChar(c byte) bool {
+	r := rune(c)
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 func isInList(w string, ws []string) bool {
 	for _, word := range ws {
 		if word == w {
@@ -156,7 +239,7 @@ func (s *parser) ParseInlineLineBreak(d *Document, line string, i int) (*InlineL
 		idx++
 	}
 	if count := idx - i; count > 0 {
-		return &InlineLineBreak{count}, count
+		return &InlineLineBreak{Count: count}, count
 	}
 	return nil, 0
 }
@@ -170,7 +253,7 @@ func (s *parser) ParseInlineBackSlash(d *Document, line string, i int) (*InlineB
 		idx++
 	}
 	if count := idx - i; count > 0 {
-		n := &InlineBackSlash{count, false}
+		n := &InlineBackSlash{Count: count}
 		for ; idx < end && unicode.IsSpace(rune(line[idx])); idx++ {
 			if line[idx] == '\n' {
 				n.Break = true
@@ -182,28 +265,17 @@ func (s *parser) ParseInlineBackSlash(d *Document, line string, i int) (*InlineB
 	return nil, 0
 }
 
-func (s *parser) ParseInlineTimestamp(d *Document, line string, i int) (*InlineTimestamp, int) {
-	if m := timestampRegexp.FindStringSubmatch(line[i:]); m != nil {
-		date, datetime, interval, isDate := m[1], m[3], strings.TrimSpace(m[4]), false
-		if datetime == "" {
-			datetime, isDate = "00:00", true
-		}
-		t, err := time.Parse(d.TimestampFormat, fmt.Sprintf("%s Mon %s", date, datetime))
-		if err != nil {
-			return nil, 0
-		}
-		return &InlineTimestamp{t, isDate, interval}, len(m[0])
-	}
-	return nil, 0
-}
-
 func (s *parser) ParseInlineFootnote(d *Document, line string, i int) (*Footnote, int) {
+	if line[i] != '[' {
+		return nil, 0
+	}
 	match := footnoteReferRegexp.FindStringSubmatch(line[i:])
 	if len(match) == 0 {
 		return nil, 0
 	}
 	fn := &Footnote{Label: match[1], Inline: true}
 	if match[3] != "" {
+		s.seek(advance(s.line, s.column, line[i:i+len(match[0])-len(match[3])-1]))
 		node, _, _ := s.ParseParagragh(d, []string{match[3]})
 		fn.Definition = []Node{node}
 	}
@@ -211,16 +283,32 @@ func (s *parser) ParseInlineFootnote(d *Document, line string, i int) (*Footnote
 }
 
 func (s *parser) ParseInlinePercent(d *Document, line string, i int) (*InlinePercent, int) {
+	if line[i] != '[' {
+		return nil, 0
+	}
 	match := percentRegexp.FindStringSubmatch(line[i:])
 	if len(match) == 0 {
 		return nil, 0
 	}
-	return &InlinePercent{match[1]}, len(match[0])
+	return &InlinePercent{Num: match[1]}, len(match[0])
 }
 
 func (s *parser) ParseInlineLink(d *Document, line string, i int) (*InlineLink, int) {
+	switch {
+	case line[i] == '<':
+		match := angleLinkRegexp.FindStringSubmatch(line[i:])
+		if len(match) > 0 && d.isLinkProtocol(match[1]) {
+			return &InlineLink{Protocol: match[1], URL: match[2]}, len(match[0])
+		}
+		return nil, 0
+	case line[i] == '[':
+		return s.parseRegularLink(d, line, i)
+	case i > 0 && isWordChar(line[i-1]):
+		// plain link must start at a word boundary
+		return nil, 0
+	}
 	match := plainLinkRegexp.FindStringSubmatch(line[i:])
-	if len(match) > 0 && isInList(match[1], d.Hyperlinks) {
+	if len(match) > 0 && d.isLinkProtocol(match[1]) {
 		start, idx := i+len(match[0]), i+len(match[0])
 		for idx < len(line) {
 			if unicode.IsSpace(rune(line[idx])) {
@@ -228,25 +316,160 @@ func (s *parser) ParseInlineLink(d *Document, line string, i int) (*InlineLink,
 			}
 			idx++
 		}
+		// trailing punctuation is not a part of plain link
+		for idx > start && strings.ContainsRune(`.,;:!?'")]}`, rune(line[idx-1])) {
+			idx--
+		}
 		if idx > start {
-			return &InlineLink{Protocol: match[1], URL: line[start:idx]}, idx
+			return &InlineLink{Protocol: match[1], URL: line[start:idx]}, idx - i
 		}
 	}
-	match = angleLinkRegexp.FindStringSubmatch(line[i:])
-	if len(match) > 0 && isInList(match[1], d.Hyperlinks) {
-		return &InlineLink{Protocol: match[1], URL: match[2]}, len(match[0])
-	}
+	return nil, 0
+}
 
-	match = regularLinkRegexp.FindStringSubmatch(line[i:])
+func (s *parser) parseRegularLink(d *Document, line string, i int) (*InlineLink, int) {
+	match := regularLinkRegexp.FindStringSubmatch(line[i:])
 	if len(match) == 0 {
 		return nil, 0
 	}
+	protocol, url := d.splitLink(match[1])
+	return &InlineLink{Protocol: protocol, URL: url, Desc: match[2]}, len(match[0])
+}
+
+func (s *parser) ParseInlineSrc(d *Document, line string, i int) (*InlineSrc, int) {
+	if line[i] != 's' || (i > 0 && isWordChar(line[i-1])) {
+		return nil, 0
+	}
+	match := inlineSrcRegexp.FindStringSubmatchIndex(line[i:])
+	if match == nil {
+		return nil, 0
+	}
+	// the body can contain balanced braces, but not newlines
+	start, depth := i+match[1], 1
+	end := start
+	for ; end < len(line) && line[end] != '\n'; end++ {
+		if line[end] == '{' {
+			depth++
+		} else if line[end] == '}' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	if depth > 0 || end >= len(line) {
+		return nil, 0
+	}
+	n := &InlineSrc{
+		Lang:    line[i+match[2] : i+match[3]],
+		Content: line[start:end],
+	}
+	if match[4] >= 0 {
+		n.Parameters = line[i+match[4] : i+match[5]]
+	}
+	n.Headers = parseAttributes(n.Parameters)
+	return n, end - i + 1
+}
+
+func (s *parser) ParseInlineExportSnippet(d *Document, line string, i int) (*ExportSnippet, int) {
+	if line[i] != '@' {
+		return nil, 0
+	}
+	match := exportSnippetRegexp.FindStringSubmatch(line[i:])
+	if match == nil {
+		return nil, 0
+	}
+	return &ExportSnippet{Backend: strings.ToLower(match[1]), Value: match[2]}, len(match[0])
+}
 
-	parts := strings.SplitN(match[1], "://", 2)
-	if len(parts) == 2 {
-		return &InlineLink{Protocol: parts[0], URL: parts[1], Desc: match[2]}, len(match[0])
+func (s *parser) ParseInlineCall(d *Document, line string, i int) (*InlineCall, int) {
+	if line[i] != 'c' || (i > 0 && isWordChar(line[i-1])) {
+		return nil, 0
+	}
+	match := inlineCallRegexp.FindStringSubmatch(line[i:])
+	if match == nil {
+		return nil, 0
 	}
-	return &InlineLink{URL: match[1], Desc: match[2]}, len(match[0])
+	n := &InlineCall{
+		Call:             match[1],
+		InsideHeaders:    match[2],
+		InsideHeaderArgs: parseAttributes(match[2]),
+		Args:             match[3],
+		EndHeaders:       match[4],
+		EndHeaderArgs:    parseAttributes(match[4]),
+	}
+	return n, len(match[0])
+}
+
+// ParseInlineScript parses subscript and superscript, which depends on
+// "^:" of #+OPTIONS, "{}" means only a_{b} is allowed and "nil" disables
+// them.
+func (s *parser) ParseInlineScript(d *Document, line string, i int) (Node, int) {
+	marker := line[i]
+	if (marker != '_' && marker != '^') || i == 0 || i+1 >= len(line) || unicode.IsSpace(rune(line[i-1])) {
+		return nil, 0
+	}
+	option := d.parseOptions().SubSuperscript
+	if option == "nil" {
+		return nil, 0
+	}
+
+	brackets, start, end := false, i+1, i+1
+	switch {
+	case line[start] == '{':
+		depth := 0
+		for end = start; end < len(line); end++ {
+			if line[end] == '{' {
+				depth++
+			} else if line[end] == '}' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if end >= len(line) || end == start+1 {
+			return nil, 0
+		}
+		brackets, start = true, start+1
+	case option == "{}":
+		return nil, 0
+	case line[start] == '*':
+		end = start + 1
+	default:
+		// [+-]?[[:alnum:],.\\]*[[:alnum:]]
+		if line[end] == '+' || line[end] == '-' {
+			end++
+		}
+		last := -1
+		for ; end < len(line); end++ {
+			c := line[end]
+			if isAlnum(c) {
+				last = end
+			} else if c != ',' && c != '.' && c != '\\' {
+				break
+			}
+		}
+		if last < 0 {
+			return nil, 0
+		}
+		end = last + 1
+	}
+
+	s.seek(s.line, s.column+start-i)
+	children := s.ParseAllInline(d, line[start:end], false)
+	size := end - i
+	if brackets {
+		size++
+	}
+	if marker == '_' {
+		return &Subscript{Brackets: brackets, Children: children}, size
+	}
+	return &Superscript{Brackets: brackets, Children: children}, size
+}
+
+func isAlnum(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
 }
 
 func (s *parser) ParseInlineEmphasis(d *Document, line string, i int) (*InlineEmphasis, int) {
@@ -268,6 +491,7 @@ func (s *parser) ParseInlineEmphasis(d *Document, line string, i int) (*InlineEm
 	idx, end := i+1, len(line)
 	for idx < end {
 		if line[idx] == marker && idx != i+1 && isValidPostBorder(line, idx+1) {
+			s.seek(s.line, s.column+1)
 			b := &InlineEmphasis{Marker: string(marker), Children: s.ParseAllInline(d, line[i+1:idx], !needparse)}
 			return b, idx - i + 1
 		}
@@ -277,26 +501,67 @@ func (s *parser) ParseInlineEmphasis(d *Document, line string, i int) (*InlineEm
 }
 
 func (s *parser) ParseInlineText(d *Document, line string, i int) (Node, Node, int) {
+	ln, column := s.line, s.column
+	nln, ncolumn := advance(ln, column, line[i:i+1])
+
 	idx, end := i+1, len(line)
 	for idx < end {
+		s.seek(nln, ncolumn)
 		if next, n := s.ParseInline(d, line, idx); next != nil {
-			return &InlineText{Content: line[i:idx]}, next, idx - i + n
+			return s.inlineText(ln, column, line[i:idx]), next, idx - i + n
 		}
+		nln, ncolumn = advance(nln, ncolumn, line[idx:idx+1])
 		idx++
 	}
-	return &InlineText{Content: line[i:idx]}, nil, idx - i
+	return s.inlineText(ln, column, line[i:idx]), nil, idx - i
+}
+
+func (s *parser) inlineText(line, column int, text string) *InlineText {
+	n := &InlineText{Content: text}
+	s.setPos(n, line, column, text)
+	return n
 }
 
 func (s *parser) ParseInline(d *Document, line string, i int) (Node, int) {
+	ln, column := s.line, s.column
+	node, n := s.parseInline(d, line, i)
+	if node != nil {
+		s.setPos(node, ln, column, line[i:i+n])
+	}
+	return node, n
+}
+
+func (s *parser) parseInline(d *Document, line string, i int) (Node, int) {
+	if node, idx := s.ParseInlineLatexFragment(d, line, i); node != nil {
+		return node, idx
+	}
+	if node, idx := s.ParseInlineEntity(d, line, i); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseInlineBackSlash(d, line, i); node != nil {
 		return node, idx
 	}
 	if node, idx := s.ParseInlineLineBreak(d, line, i); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseInlineExportSnippet(d, line, i); node != nil {
+		return node, idx
+	}
+	if node, idx := s.ParseInlineSrc(d, line, i); node != nil {
+		return node, idx
+	}
+	if node, idx := s.ParseInlineCall(d, line, i); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseInlineEmphasis(d, line, i); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseInlineScript(d, line, i); node != nil {
+		return node, idx
+	}
+	if node, idx := s.ParseInlineTarget(d, line, i); node != nil {
+		return node, idx
+	}
 	if node, idx := s.ParseInlineLink(d, line, i); node != nil {
 		return node, idx
 	}
@@ -309,17 +574,25 @@ func (s *parser) ParseInline(d *Document, line string, i int) (Node, int) {
 	if node, idx := s.ParseInlineTimestamp(d, line, i); node != nil {
 		return node, idx
 	}
+	if node, idx := s.ParseInlineMacro(d, line, i); node != nil {
+		return node, idx
+	}
 	return nil, i
 }
 
 func (s *parser) ParseAllInline(d *Document, line string, raw bool) []Node {
+	ln, column := s.line, s.column
 	if raw {
-		return []Node{&InlineText{Content: line, Raw: raw}}
+		n := &InlineText{Content: line, Raw: raw}
+		s.setPos(n, ln, column, line)
+		return []Node{n}
 	}
 	idx, end, nodes := 0, len(line), make([]Node, 0)
 	for idx < end {
+		s.seek(ln, column)
 		if node, i := s.ParseInline(d, line, idx); node != nil {
 			nodes = append(nodes, node)
+			ln, column = advance(ln, column, line[idx:idx+i])
 			idx = idx + i
 			continue
 		}
@@ -330,6 +603,7 @@ func (s *parser) ParseAllInline(d *Document, line string, raw bool) []Node {
 		if next != nil {
 			nodes = append(nodes, next)
 		}
+		ln, column = advance(ln, column, line[idx:idx+i])
 		idx = idx + i
 	}
 	return nodes
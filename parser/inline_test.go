@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineSrc(t *testing.T) {
+	d := newDocument()
+	nodes := ParseFromText(d, `Run src_sh[:exports code]{echo {a}} or src_go{fmt.Println("x")} and call_square[:results raw :var y=2](x=4)[:exports both].`)
+
+	children := nodes[0].(*Paragragh).Children
+	src := children[1].(*InlineSrc)
+	assert.Equal(t, "sh", src.Lang)
+	assert.Equal(t, "echo {a}", src.Content)
+	assert.Equal(t, map[string]string{"exports": "code"}, src.Headers)
+	assert.Equal(t, Span{Position{1, 5, 4}, Position{1, 36, 35}}, src.Pos())
+	assert.Equal(t, `fmt.Println("x")`, children[3].(*InlineSrc).Content)
+
+	call := children[5].(*InlineCall)
+	assert.Equal(t, "square", call.Call)
+	assert.Equal(t, ":results raw :var y=2", call.InsideHeaders)
+	assert.Equal(t, map[string]string{"results": "raw", "var": "y=2"}, call.InsideHeaderArgs)
+	assert.Equal(t, "x=4", call.Args)
+	assert.Equal(t, ":exports both", call.EndHeaders)
+	assert.Equal(t, map[string]string{"exports": "both"}, call.EndHeaderArgs)
+
+	nodes = ParseFromText(d, "call_square(4)")
+	call = nodes[0].(*Paragragh).Children[0].(*InlineCall)
+	assert.Empty(t, call.InsideHeaderArgs)
+	assert.Empty(t, call.EndHeaderArgs)
+
+	nodes = ParseFromText(d, "mysrc_go{x} src_go{x")
+	for _, child := range nodes[0].(*Paragragh).Children {
+		assert.NotEqual(t, InlineSrcName, child.Name())
+	}
+}
+
+func TestExportSnippet(t *testing.T) {
+	d := newDocument()
+	nodes := ParseFromText(d, "a @@HTML:<b>@@ b\n#+LaTeX: \\newpage\n#+HTML_HEAD: <style/>")
+
+	snippet := nodes[0].(*Paragragh).Children[1].(*ExportSnippet)
+	assert.Equal(t, "html", snippet.Backend)
+	assert.Equal(t, "<b>", snippet.Value)
+	assert.Equal(t, Span{Position{1, 3, 2}, Position{1, 15, 14}}, snippet.Pos())
+
+	keyword := nodes[1].(*ExportKeyword)
+	assert.Equal(t, "latex", keyword.Backend)
+	assert.Equal(t, "\\newpage", keyword.Value)
+	assert.Equal(t, KeywordName, nodes[2].Name())
+	assert.Equal(t, "<style/>", d.Get("HTML_HEAD"))
+}
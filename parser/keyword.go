@@ -2,31 +2,47 @@ package parser
 
 import (
 	"regexp"
+	"strings"
 )
 
 const (
-	KeywordName     = "Keyword"
-	keywordAttrName = "KeywordAttr"
+	KeywordName       = "Keyword"
+	ExportKeywordName = "ExportKeyword"
 )
 
 var (
-	keywordRegexp = regexp.MustCompile(`^(\s*)#\+([^:]+):(\s+(.*)|\n|$)`)
+	keywordRegexp    = regexp.MustCompile(`^(\s*)#\+([^:]+):(\s+(.*)|\n|$)`)
+	affiliatedRegexp = regexp.MustCompile(`(?i)^(\s*)#\+(NAME|CAPTION(?:\[(.*)\])?|ATTR_([-\w]+)):(\s+(.*)|$)`)
+	attributeRegexp  = regexp.MustCompile(`(?:^|\s+):([-\w]+)`)
+	// export keywords are only exported by their backend
+	exportKeywordRegexp = regexp.MustCompile(`(?i)^(\s*)#\+(ASCII|BEAMER|HTML|LATEX|MAN|MD|ODT|TEXINFO):(?:\s(.*)|$)`)
 )
 
-type WithKeyword struct {
-	Caption   map[string][]string
-	HTMLAttrs map[string][]string
-	Node   Node
+// Affiliated holds the affiliated keywords (#+NAME, #+CAPTION and
+// #+ATTR_BACKEND) which are attached to the next table, block, list or
+// link-only paragraph.
+type Affiliated struct {
+	Name         string
+	Caption      []Node
+	ShortCaption []Node
+	// backend in lower case, such as html or latex, to attributes
+	Attributes map[string]map[string]string
+	Keywords   []*Keyword
 }
 
-type Keyword struct {
-	Key   string
-	Value string
+// Attrs returns the attributes of backend, it's safe to call on a nil Affiliated
+func (s *Affiliated) Attrs(backend string) map[string]string {
+	if s == nil {
+		return nil
+	}
+	return s.Attributes[strings.ToLower(backend)]
 }
 
-type KeywordAttr struct {
+type Keyword struct {
+	Span
+
 	Key   string
-	Value map[string]string
+	Value string
 }
 
 func (Keyword) Name() string {
@@ -37,6 +53,28 @@ func (s *Keyword) Get(string) (string, bool) {
 	return "", true
 }
 
+// ExportKeyword is #+BACKEND: value, such as #+HTML: <br/>, Backend is
+// in lower case.
+type ExportKeyword struct {
+	Span
+
+	Key     string
+	Backend string
+	Value   string
+}
+
+func (ExportKeyword) Name() string {
+	return ExportKeywordName
+}
+
+func (s *parser) ParseExportKeyword(d *Document, lines []string) (*ExportKeyword, int) {
+	match := exportKeywordRegexp.FindStringSubmatch(lines[0])
+	if match == nil {
+		return nil, 0
+	}
+	return &ExportKeyword{Key: match[2], Backend: strings.ToLower(match[2]), Value: match[3]}, 1
+}
+
 func (s *parser) ParseKeyword(d *Document, lines []string) (*Keyword, int) {
 	match := keywordRegexp.FindStringSubmatch(lines[0])
 	if match == nil {
@@ -46,14 +84,135 @@ func (s *parser) ParseKeyword(d *Document, lines []string) (*Keyword, int) {
 		Key:   match[2],
 		Value: match[4],
 	}
-	switch node.Key {
-	case "CAPTION", "ATTR_HTML":
-		// next, n := s.Parse(lines[1:])
-		// if next != nil {
+	if !affiliatedRegexp.MatchString(lines[0]) {
+		d.setKeyword(node.Key, node.Value)
+	}
+	return node, 1
+}
 
-		// }
+// setKeyword applies the in-buffer setting of #+KEY: value to the document
+func (d *Document) setKeyword(key, value string) {
+	switch strings.ToUpper(key) {
+	case "MACRO":
+		d.parseMacroDefinition(value)
+	case "LINK":
+		d.parseLinkAbbrev(value)
+	case "TODO", "SEQ_TODO", "TYP_TODO", "PRIORITIES", "OPTIONS", "SETUPFILE":
+		// collected by parseSettings before parsing
+	case "PROPERTY":
+		if d.Properties == nil {
+			d.Properties = make(map[string]string)
+		}
+		if v := strings.SplitN(strings.TrimSpace(value), " ", 2); len(v) == 2 {
+			setProperty(d.Properties, v[0], strings.TrimSpace(v[1]))
+		} else if v[0] != "" {
+			setProperty(d.Properties, v[0], "")
+		}
 	default:
-		d.Set(match[2], match[4])
+		d.Set(key, value)
 	}
-	return node, 1
+}
+
+// parseAttributes parses ":key value :key1 value1" into a map
+func parseAttributes(text string) map[string]string {
+	attrs := make(map[string]string)
+
+	matches := attributeRegexp.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range matches {
+		end := len(text)
+		if i < len(matches)-1 {
+			end = matches[i+1][0]
+		}
+		value := strings.TrimSpace(text[m[1]:end])
+		if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		attrs[text[m[2]:m[3]]] = value
+	}
+	return attrs
+}
+
+func (s *parser) ParseAffiliated(d *Document, lines []string) (*Affiliated, int) {
+	line, column := s.line, s.column
+
+	var b *Affiliated
+
+	idx, end := 0, len(lines)
+	for idx < end {
+		match := affiliatedRegexp.FindStringSubmatchIndex(lines[idx])
+		if match == nil {
+			break
+		}
+		if b == nil {
+			b = &Affiliated{Attributes: make(map[string]map[string]string)}
+		}
+		text := lines[idx]
+		value := strings.TrimSpace(text[match[10]:match[11]])
+		start := len(text) - len(strings.TrimLeft(text[match[10]:], " \t"))
+
+		s.seekLine(line, column, idx)
+		keyword := &Keyword{Key: text[match[4]:match[5]], Value: value}
+		s.setPos(keyword, s.line, s.column, text)
+		b.Keywords = append(b.Keywords, keyword)
+
+		switch key := strings.ToUpper(keyword.Key); {
+		case key == "NAME":
+			b.Name = value
+		case strings.HasPrefix(key, "CAPTION"):
+			if match[6] >= 0 {
+				s.seek(s.line, s.column+match[6])
+				b.ShortCaption = s.ParseAllInline(d, text[match[6]:match[7]], false)
+			}
+			if value == "" {
+				break
+			}
+			if len(b.Caption) > 0 {
+				b.Caption = append(b.Caption, &InlineText{Content: " "})
+			}
+			s.seekLine(line, column, idx)
+			s.seek(s.line, s.column+start)
+			b.Caption = append(b.Caption, s.ParseAllInline(d, value, false)...)
+		default:
+			backend := strings.ToLower(text[match[8]:match[9]])
+			attrs, ok := b.Attributes[backend]
+			if !ok {
+				attrs = make(map[string]string)
+				b.Attributes[backend] = attrs
+			}
+			for k, v := range parseAttributes(value) {
+				attrs[k] = v
+			}
+		}
+		idx++
+	}
+	return b, idx
+}
+
+// affiliate attaches the affiliated keywords to node, if node doesn't
+// accept them, the keywords are kept as normal nodes before node.
+func affiliate(node Node, aff *Affiliated) []Node {
+	if aff == nil {
+		return []Node{node}
+	}
+	switch n := node.(type) {
+	case *Table:
+		n.Affiliated = aff
+		return []Node{n}
+	case *Block:
+		n.Affiliated = aff
+		return []Node{n}
+	case *List:
+		n.Affiliated = aff
+		return []Node{n}
+	case *Paragragh:
+		if n.Link() != nil {
+			n.Affiliated = aff
+			return []Node{n}
+		}
+	}
+	nodes := make([]Node, 0, len(aff.Keywords)+1)
+	for _, keyword := range aff.Keywords {
+		nodes = append(nodes, keyword)
+	}
+	return append(nodes, node)
 }
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	LatexFragmentName    = "LatexFragment"
+	LatexEnvironmentName = "LatexEnvironment"
+)
+
+var (
+	beginLatexRegexp = regexp.MustCompile(`^(\s*)\\begin\{([A-Za-z0-9*]+)\}`)
+	endLatexRegexp   = regexp.MustCompile(`^(\s*)\\end\{([A-Za-z0-9*]+)\}\s*$`)
+)
+
+// latexDelimiters maps the opening delimiter to the closing one
+var latexDelimiters = map[string]string{
+	`\(`: `\)`,
+	`\[`: `\]`,
+	`$$`: `$$`,
+	`$`:  `$`,
+}
+
+// LatexFragment is inline math like \(x\), \[x\], $x$ or $$x$$
+type LatexFragment struct {
+	Span
+
+	Delimiter string
+	Content   string
+}
+
+func (LatexFragment) Name() string {
+	return LatexFragmentName
+}
+
+// Display reports whether the fragment is display math
+func (s *LatexFragment) Display() bool {
+	return s.Delimiter == `\[` || s.Delimiter == "$$"
+}
+
+// Close returns the closing delimiter
+func (s *LatexFragment) Close() string {
+	return latexDelimiters[s.Delimiter]
+}
+
+// LatexEnvironment is \begin{ENV} ... \end{ENV}, Content contains the
+// whole environment
+type LatexEnvironment struct {
+	Span
+
+	Env     string
+	Content string
+}
+
+func (LatexEnvironment) Name() string {
+	return LatexEnvironmentName
+}
+
+func (s *parser) ParseLatexEnvironment(d *Document, lines []string) (*LatexEnvironment, int) {
+	match := beginLatexRegexp.FindStringSubmatch(lines[0])
+	if match == nil {
+		return nil, 0
+	}
+	for idx := 1; idx < len(lines); idx++ {
+		if m := endLatexRegexp.FindStringSubmatch(lines[idx]); m != nil && m[2] == match[2] {
+			return &LatexEnvironment{Env: match[2], Content: strings.Join(lines[:idx+1], "\n")}, idx + 1
+		}
+	}
+	s.warn(d, s.line, s.column, `unterminated \begin{%s}`, match[2])
+	return nil, 0
+}
+
+func (s *parser) ParseInlineLatexFragment(d *Document, line string, i int) (*LatexFragment, int) {
+	switch line[i] {
+	case '\\':
+		if i+1 >= len(line) || (line[i+1] != '(' && line[i+1] != '[') {
+			return nil, 0
+		}
+		open := line[i : i+2]
+		if idx := strings.Index(line[i+2:], latexDelimiters[open]); idx >= 0 {
+			return &LatexFragment{Delimiter: open, Content: line[i+2 : i+2+idx]}, idx + 4
+		}
+	case '$':
+		if i > 0 && (line[i-1] == '$' || line[i-1] == '\\') {
+			return nil, 0
+		}
+		if strings.HasPrefix(line[i:], "$$") {
+			if idx := strings.Index(line[i+2:], "$$"); idx > 0 {
+				return &LatexFragment{Delimiter: "$$", Content: line[i+2 : i+2+idx]}, idx + 4
+			}
+			return nil, 0
+		}
+		// $ must not be followed by whitespace or punctuation, and the
+		// closing $ must not be preceded by them
+		if i+1 >= len(line) || strings.ContainsRune(" \t\n.,;$", rune(line[i+1])) {
+			return nil, 0
+		}
+		for idx := i + 1; idx < len(line); idx++ {
+			if line[idx] != '$' {
+				continue
+			}
+			if strings.ContainsRune(" \t\n.,$", rune(line[idx-1])) || !isValidPostBorder(line, idx+1) {
+				return nil, 0
+			}
+			return &LatexFragment{Delimiter: "$", Content: line[i+1 : idx]}, idx - i + 1
+		}
+	}
+	return nil, 0
+}
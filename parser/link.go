@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// LinkHandler returns the final url, description and type of a link
+type LinkHandler func(d *Document, link *InlineLink) (string, string, LinkType)
+
+// PrefixLinkHandler returns the link handler which prepends prefix to
+// the url of links, such as "https://" or "https://doi.org/".
+func PrefixLinkHandler(prefix string) LinkHandler {
+	return func(d *Document, link *InlineLink) (string, string, LinkType) {
+		u := prefix + link.URL
+		if link.Desc != "" {
+			return u, link.Desc, link.Type()
+		}
+		return u, u, link.Type()
+	}
+}
+
+// DefaultLinkHandlers returns the handlers of http, https, file and
+// mailto links
+func DefaultLinkHandlers() map[string]LinkHandler {
+	return map[string]LinkHandler{
+		"http":   PrefixLinkHandler("http://"),
+		"https":  PrefixLinkHandler("https://"),
+		"file":   PrefixLinkHandler(""),
+		"mailto": PrefixLinkHandler("mailto:"),
+	}
+}
+
+// SetLinkHandler registers the handler of protocol, links of protocol
+// are recognized by the parser only if it has a handler.
+func (d *Document) SetLinkHandler(protocol string, fn LinkHandler) {
+	if d.LinkHandlers == nil {
+		d.LinkHandlers = make(map[string]LinkHandler)
+	}
+	d.LinkHandlers[protocol] = fn
+}
+
+func (d *Document) isLinkProtocol(protocol string) bool {
+	_, ok := d.LinkHandlers[protocol]
+	return ok || isInList(protocol, d.Hyperlinks)
+}
+
+// syncHyperlinks adds the protocols of LinkHandlers to the deprecated
+// Hyperlinks, so that the callers of Hyperlinks still see them
+func (d *Document) syncHyperlinks() {
+	protocols := make([]string, 0, len(d.LinkHandlers))
+	for protocol := range d.LinkHandlers {
+		if !isInList(protocol, d.Hyperlinks) {
+			protocols = append(protocols, protocol)
+		}
+	}
+	sort.Strings(protocols)
+	d.Hyperlinks = append(d.Hyperlinks, protocols...)
+}
+
+// ResolveLink returns the final url, description and type of link with
+// the handler of its protocol, protocol://url is returned if there is
+// no handler.
+func (d *Document) ResolveLink(link *InlineLink) (string, string, LinkType) {
+	if fn := d.LinkHandlers[link.Protocol]; fn != nil {
+		return fn(d, link)
+	}
+	if link.Protocol == "" {
+		return PrefixLinkHandler("")(d, link)
+	}
+	return PrefixLinkHandler(link.Protocol+"://")(d, link)
+}
+
+// SetLinkAbbrev registers a link abbreviation, [[name:tag]] is expanded
+// with replacement, in which "%s" is replaced by tag and "%h" by the
+// url encoded tag, otherwise tag is appended to replacement.
+func (d *Document) SetLinkAbbrev(name, replacement string) {
+	if d.LinkAbbrevs == nil {
+		d.LinkAbbrevs = make(map[string]string)
+	}
+	d.LinkAbbrevs[strings.ToLower(name)] = replacement
+}
+
+// parseLinkAbbrev parses the value of #+LINK
+func (d *Document) parseLinkAbbrev(value string) {
+	v := strings.Fields(value)
+	if len(v) < 2 {
+		return
+	}
+	d.SetLinkAbbrev(v[0], strings.Join(v[1:], " "))
+}
+
+// splitLink returns the protocol and url of the text of a link
+func (d *Document) splitLink(text string) (string, string) {
+	if parts := strings.SplitN(text, "://", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	if parts := strings.SplitN(text, ":", 2); len(parts) == 2 && d.isLinkProtocol(parts[0]) {
+		return parts[0], parts[1]
+	}
+	return "", text
+}
+
+// expandLink returns the expansion of the link abbreviation in text
+func (d *Document) expandLink(text string) (string, bool) {
+	parts := strings.SplitN(text, ":", 2)
+	replacement, ok := d.LinkAbbrevs[strings.ToLower(parts[0])]
+	if !ok {
+		return "", false
+	}
+	tag := ""
+	if len(parts) == 2 {
+		tag = parts[1]
+	}
+	switch {
+	case strings.Contains(replacement, "%s"):
+		return strings.Replace(replacement, "%s", tag, -1), true
+	case strings.Contains(replacement, "%h"):
+		return strings.Replace(replacement, "%h", strings.Replace(url.QueryEscape(tag), "+", "%20", -1), -1), true
+	default:
+		return replacement + tag, true
+	}
+}
+
+// expandLinks expands the link abbreviations after the document is
+// parsed, so #+LINK can be defined anywhere
+func (s *parser) expandLinks(d *Document, nodes []Node) {
+	if len(d.LinkAbbrevs) == 0 {
+		return
+	}
+	Walk(nodes, func(node Node) bool {
+		n, ok := node.(*InlineLink)
+		if !ok || n.Protocol != "" {
+			return true
+		}
+		if text, ok := d.expandLink(n.URL); ok {
+			n.Protocol, n.URL = d.splitLink(text)
+		}
+		return true
+	})
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkAbbrev(t *testing.T) {
+	d := newDocument()
+	d.SetLinkAbbrev("wiki", "https://en.wikipedia.org/wiki/")
+	nodes := ParseFromText(d, `#+LINK: gh https://github.com/%s
+#+LINK: search https://duckduckgo.com/?q=%h
+[[gh:honmaple/org-golang]] [[search:org mode][search]] [[wiki:Org-mode]] [[gh]]`)
+
+	urls := make([]string, 0)
+	for _, child := range nodes[2].(*Paragragh).Children {
+		if link, ok := child.(*InlineLink); ok {
+			urls = append(urls, link.Protocol+"://"+link.URL)
+		}
+	}
+	assert.Equal(t, []string{
+		"https://github.com/honmaple/org-golang",
+		"https://duckduckgo.com/?q=org%20mode",
+		"https://en.wikipedia.org/wiki/Org-mode",
+		"https://github.com/",
+	}, urls)
+	assert.Empty(t, d.Diagnostics)
+}
+
+func TestLinkHandler(t *testing.T) {
+	d := newDocument()
+	d.SetLinkHandler("jira", func(d *Document, link *InlineLink) (string, string, LinkType) {
+		return "https://jira.example.com/browse/" + link.URL, "Issue " + link.URL, RegularLink
+	})
+	nodes := ParseFromText(d, "Mail mailto:me@example.com about jira:ORG-1, not foo:bar or xjira:ORG-2.")
+
+	children := nodes[0].(*Paragragh).Children
+	mail := children[1].(*InlineLink)
+	assert.Equal(t, "mailto", mail.Protocol)
+	assert.Equal(t, "me@example.com", mail.URL)
+	assert.Equal(t, Span{Position{1, 6, 5}, Position{1, 27, 26}}, mail.Pos())
+
+	url, desc, typ := d.ResolveLink(mail)
+	assert.Equal(t, "mailto:me@example.com", url)
+	assert.Equal(t, "mailto:me@example.com", desc)
+	assert.Equal(t, RegularLink, typ)
+
+	url, desc, _ = d.ResolveLink(children[3].(*InlineLink))
+	assert.Equal(t, "https://jira.example.com/browse/ORG-1", url)
+	assert.Equal(t, "Issue ORG-1", desc)
+	assert.Len(t, children, 5)
+
+	url, _, typ = d.ResolveLink(&InlineLink{Protocol: "https", URL: "example.com/a.png"})
+	assert.Equal(t, "https://example.com/a.png", url)
+	assert.Equal(t, ImageLink, typ)
+}
+
+func TestHyperlinks(t *testing.T) {
+	d := &Document{Sections: &Section{}, Hyperlinks: []string{"http", "ftp"}}
+	nodes := ParseFromText(d, "ftp://example.com/a and mailto:me@example.com")
+
+	link := nodes[0].(*Paragragh).Children[0].(*InlineLink)
+	assert.Equal(t, "ftp", link.Protocol)
+	assert.Equal(t, "example.com/a", link.URL)
+	url, _, _ := d.ResolveLink(link)
+	assert.Equal(t, "ftp://example.com/a", url)
+	assert.Len(t, nodes[0].(*Paragragh).Children, 2)
+
+	d = newDocument()
+	ParseFromText(d, "text")
+	assert.Equal(t, []string{"file", "http", "https", "mailto"}, d.Hyperlinks)
+}
@@ -2,47 +2,54 @@ package parser
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 const (
-	ListName             = "List"
-	ListItemName         = "ListItem"
-	OrderlistName        = "OrderList"
-	UnorderlistName      = "UnorderList"
-	DescriptiveName      = "Descriptive"
-	descriptiveDescName  = "DescriptiveDesc"
-	descriptiveTitleName = "DescriptiveTitle"
-	DescriptiveItemName  = "DescriptiveItem"
+	ListName            = "List"
+	ListItemName        = "ListItem"
+	OrderlistName       = "OrderList"
+	UnorderlistName     = "UnorderList"
+	DescriptiveName     = "Descriptive"
+	DescriptiveItemName = "DescriptiveItem"
 )
 
 var (
 	listRegexp        = regexp.MustCompile(`^(\s*)(([0-9]+|[a-zA-Z])[.)]|[+*-])(\s+(.*)|$)`)
-	descriptiveRegexp = regexp.MustCompile(`^(\s*)([+*-])\s+(.*)::(\s|$)`)
-	listStatusRegexp  = regexp.MustCompile(`\[( |X|-)\]\s`)
+	descriptiveRegexp = regexp.MustCompile(`^(.*\S)\s+::(?:\s+|$)`)
+	listCounterRegexp = regexp.MustCompile(`^\[@(?:start:)?([0-9]+|[a-zA-Z])\](?:\s+|$)`)
+	listStatusRegexp  = regexp.MustCompile(`^\[( |X|-)\](?:\s+|$)`)
 	levelRegexp       = regexp.MustCompile(`(\s*)(.+)$`)
 )
 
 type List struct {
-	Type     string
-	Level    int
-	Children []Node
+	Span
+
+	Type       string
+	Level      int
+	Affiliated *Affiliated
+	Children   []Node
 }
 
 type ListItem struct {
+	Span
+
 	Level    int
 	Bullet   string
+	Counter  string
 	Status   string
-	Title    string
+	Title    []Node
 	Children []Node
 }
 
 type DescriptiveItem struct {
+	Span
+
 	Level    int
 	Bullet   string
 	Status   string
-	Title    string
-	Descs    []Node
+	Title    []Node
 	Children []Node
 }
 
@@ -61,70 +68,171 @@ func (s ListItem) Kind() string {
 	return OrderlistName
 }
 
+// Start returns the number of [@N] counter, alphabetical counter
+// starts from 1 (a or A), 0 means no counter.
+func (s ListItem) Start() int {
+	if s.Counter == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(s.Counter); err == nil {
+		return n
+	}
+	return int(s.Counter[0]|0x20-'a') + 1
+}
+
 func (DescriptiveItem) Name() string {
 	return DescriptiveItemName
 }
 
-func (s *parser) ParseListItem(d *Document, lines []string) (*ListItem, int) {
-	match := listRegexp.FindStringSubmatch(lines[0])
+// parseBullet returns the level, bullet, counter, status and the
+// remaining title of a list item line.
+func parseBullet(line string) (int, string, string, string, string, bool) {
+	match := listRegexp.FindStringSubmatch(line)
 	if match == nil {
-		return nil, 0
+		return 0, "", "", "", "", false
+	}
+	counter, status, title := "", "", match[5]
+	if m := listCounterRegexp.FindStringSubmatch(title); m != nil {
+		counter, title = m[1], title[len(m[0]):]
 	}
-	status, title := "", match[4]
 	if m := listStatusRegexp.FindStringSubmatch(title); m != nil {
-		status, title = m[1], title[len("[ ] "):]
+		status, title = m[1], title[len(m[0]):]
+	}
+	return lineIndent(match[1]), match[2], counter, status, title, true
+}
+
+func (s *parser) ParseListItem(d *Document, lines []string) (*ListItem, int) {
+	level, bullet, counter, status, title, ok := parseBullet(lines[0])
+	if !ok {
+		return nil, 0
 	}
+	line, column := s.line, s.column
+
 	b := &ListItem{
-		Level:  len(match[1]),
-		Title:  title,
+		Level:   level,
+		Bullet:  bullet,
+		Counter: counter,
+		Status:  status,
+	}
+	idx := listItemEnd(lines, b.Level)
+	if title == "" {
+		s.seekLine(line, column, 1)
+		b.Children = s.ParseAll(d, lines[1:idx], false)
+	} else {
+		s.seek(line, column+len(lines[0])-len(title))
+		b.Children = s.ParseAll(d, append([]string{title}, lines[1:idx]...), false)
+		// the first paragraph is the title of item
+		if p, ok := b.Children[0].(*Paragragh); ok {
+			b.Title, b.Children = p.Children, b.Children[1:]
+		}
+	}
+	s.setLinesPos(b, line, column, lines[:idx])
+	return b, idx
+}
+
+// ParseDescriptiveItem parses "- term :: description", the term is
+// parsed as inline nodes, the description and the following lines
+// are the children of the item.
+func (s *parser) ParseDescriptiveItem(d *Document, lines []string) (*DescriptiveItem, int) {
+	level, bullet, counter, status, title, ok := parseBullet(lines[0])
+	if !ok || counter != "" || !strings.ContainsAny(bullet, "-*+") {
+		return nil, 0
+	}
+	tmatch := descriptiveRegexp.FindStringSubmatchIndex(title)
+	if tmatch == nil {
+		return nil, 0
+	}
+	line, column := s.line, s.column
+	offset := len(lines[0]) - len(title)
+
+	b := &DescriptiveItem{
+		Level:  level,
 		Status: status,
-		Bullet: match[2],
+		Bullet: bullet,
 	}
-	spa := 0
+	s.seek(line, column+offset)
+	b.Title = s.ParseAllInline(d, title[:tmatch[3]], false)
+
+	idx := listItemEnd(lines, b.Level)
+	if desc := title[tmatch[1]:]; desc != "" {
+		s.seek(line, column+offset+tmatch[1])
+		b.Children = s.ParseAll(d, append([]string{desc}, lines[1:idx]...), false)
+	} else if idx > 1 {
+		s.seekLine(line, column, 1)
+		b.Children = s.ParseAll(d, lines[1:idx], false)
+	}
+	s.setLinesPos(b, line, column, lines[:idx])
+	return b, idx
+}
+
+// listItemEnd returns the number of lines belong to the item, the item
+// ends at a line not indented deeper than the bullet or two blank
+// lines which are not in a block.
+func listItemEnd(lines []string, level int) int {
+	block, spa := "", 0
 	idx, end := 1, len(lines)
 	for idx < end {
 		if isBlankline(lines[idx]) {
 			spa++
-			// 连续两次空行
-			// 如果空行在某个block内会有问题，暂时注释
-			// if spa == 2 {
-			//	break
-			// }
+			if spa == 2 && block == "" {
+				return idx - 1
+			}
 			idx++
 			continue
 		}
 		spa = 0
-		if level := lineIndent(lines[idx]); level <= b.Level {
+		if lineIndent(lines[idx]) <= level {
 			break
 		}
+		if block == "" {
+			if m := beginBlockRegexp.FindStringSubmatch(lines[idx]); m != nil {
+				block = strings.ToUpper(m[2])
+			}
+		} else if m := endBlockRegexp.FindStringSubmatch(lines[idx]); m != nil && strings.ToUpper(m[2]) == block {
+			block = ""
+		}
 		idx++
 	}
-	b.Children = s.ParseAll(d, append([]string{title}, lines[1:idx]...), false)
-	return b, idx
+	return idx
+}
+
+func (s *parser) parseListItem(d *Document, lines []string) (Node, string, int, int) {
+	if item, n := s.ParseDescriptiveItem(d, lines); item != nil {
+		return item, DescriptiveName, item.Level, n
+	}
+	if item, n := s.ParseListItem(d, lines); item != nil {
+		return item, item.Kind(), item.Level, n
+	}
+	return nil, "", 0, 0
 }
 
+// ParseList parses the items with same indentation, ordered and
+// unordered items can be mixed in one list, the type of list is
+// decided by the first item.
 func (s *parser) ParseList(d *Document, lines []string) (*List, int) {
-	item, idx := s.ParseListItem(d, lines)
+	line, column := s.line, s.column
+	item, typ, level, idx := s.parseListItem(d, lines)
 	if item == nil {
 		return nil, 0
 	}
 	l := &List{
-		Type:     item.Kind(),
+		Type:     typ,
+		Level:    level,
 		Children: []Node{item},
 	}
 
 	end := len(lines)
 	for idx < end {
-		if level := lineIndent(lines[idx]); level < item.Level {
+		if lineIndent(lines[idx]) != l.Level {
 			break
 		}
-		item, ln := s.ParseListItem(d, lines[idx:])
-		if item != nil && item.Level == item.Level && item.Kind() == l.Type {
-			l.Children = append(l.Children, item)
-			idx = idx + ln
-			continue
+		s.seekLine(line, column, idx)
+		item, typ, _, ln := s.parseListItem(d, lines[idx:])
+		if item == nil || (typ == DescriptiveName) != (l.Type == DescriptiveName) {
+			break
 		}
-		break
+		l.Children = append(l.Children, item)
+		idx = idx + ln
 	}
 	return l, idx
 }
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptiveList(t *testing.T) {
+	text := `- *Go* :: a language
+  with goroutines
+- [X] C++ :: the :: is kept
+- Lisp ::
+  parens
+- plain item`
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	list := nodes[0].(*List)
+	assert.Equal(t, DescriptiveName, list.Type)
+	assert.Len(t, list.Children, 3)
+
+	item := list.Children[0].(*DescriptiveItem)
+	assert.Equal(t, InlineEmphasisName, item.Title[0].Name())
+	assert.Equal(t, Span{Position{1, 3, 2}, Position{1, 7, 6}}, item.Title[0].Pos())
+	p := item.Children[0].(*Paragragh)
+	assert.Equal(t, Span{Position{1, 11, 10}, Position{2, 18, 38}}, p.Pos())
+
+	item = list.Children[1].(*DescriptiveItem)
+	assert.Equal(t, "X", item.Status)
+	assert.Equal(t, "C++ :: the", item.Title[0].(*InlineText).Content)
+
+	item = list.Children[2].(*DescriptiveItem)
+	assert.Equal(t, "Lisp", item.Title[0].(*InlineText).Content)
+	assert.Equal(t, ParagraghName, item.Children[0].Name())
+
+	assert.Equal(t, UnorderlistName, nodes[1].(*List).Type)
+}
+
+func TestNestedList(t *testing.T) {
+	text := "- one\n" +
+		"\t1. [@3] tab indented\n" +
+		"        2. [X] same level\n" +
+		"+ two\n" +
+		"  #+begin_example\n" +
+		"\n" +
+		"\n" +
+		"  #+end_example\n" +
+		"\n" +
+		"\n" +
+		"- new list"
+
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+
+	list := nodes[0].(*List)
+	assert.Equal(t, UnorderlistName, list.Type)
+	assert.Len(t, list.Children, 2)
+
+	item := list.Children[0].(*ListItem)
+	assert.Equal(t, "one", item.Title[0].(*InlineText).Content)
+	nested := item.Children[0].(*List)
+	assert.Equal(t, OrderlistName, nested.Type)
+	assert.Equal(t, 8, nested.Level)
+	assert.Len(t, nested.Children, 2)
+	assert.Equal(t, 3, nested.Children[0].(*ListItem).Start())
+	assert.Equal(t, "X", nested.Children[1].(*ListItem).Status)
+
+	item = list.Children[1].(*ListItem)
+	assert.Equal(t, "+", item.Bullet)
+	assert.Equal(t, BlockName, item.Children[0].Name())
+
+	assert.Equal(t, BlanklineName, nodes[1].Name())
+	assert.Equal(t, ListName, nodes[2].Name())
+}
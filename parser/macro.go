@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const MacroName = "Macro"
+
+var (
+	macroRegexp         = regexp.MustCompile(`(?s)^\{\{\{([a-zA-Z][-\w]*)(?:\((.*?)\))?\}\}\}`)
+	macroTemplateRegexp = regexp.MustCompile(`\$(\d+)`)
+)
+
+// maxMacroDepth limits the nested expansion of macros
+const maxMacroDepth = 16
+
+// MacroFunc returns the expansion of a macro, heading is the heading
+// which contains the macro, or nil if the macro is out of headings.
+type MacroFunc func(d *Document, heading *Heading, args []string) string
+
+// Macro is {{{name(arg1,arg2)}}}, Children is the expansion parsed as
+// inline nodes.
+type Macro struct {
+	Span
+
+	Key      string
+	Args     []string
+	Children []Node
+}
+
+func (Macro) Name() string {
+	return MacroName
+}
+
+// SetMacro registers a macro, it should be called before parsing, and
+// #+MACRO in the document with the same name takes precedence.
+func (d *Document) SetMacro(name string, fn MacroFunc) {
+	if d.Macros == nil {
+		d.Macros = make(map[string]MacroFunc)
+	}
+	d.Macros[strings.ToLower(name)] = fn
+}
+
+// templateMacro returns the macro of "#+MACRO: name template", $1, $2 ...
+// in template are replaced by the arguments
+func templateMacro(template string) MacroFunc {
+	return func(d *Document, heading *Heading, args []string) string {
+		return macroTemplateRegexp.ReplaceAllStringFunc(template, func(s string) string {
+			if n, _ := strconv.Atoi(s[1:]); n > 0 && n <= len(args) {
+				return args[n-1]
+			}
+			return ""
+		})
+	}
+}
+
+// parseMacroDefinition parses the value of #+MACRO
+func (d *Document) parseMacroDefinition(value string) {
+	v := strings.SplitN(strings.TrimSpace(value), " ", 2)
+	if v[0] == "" {
+		return
+	}
+	template := ""
+	if len(v) == 2 {
+		template = strings.TrimSpace(v[1])
+	}
+	d.SetMacro(v[0], templateMacro(template))
+}
+
+// splitMacroArgs splits the arguments by comma, "\," is an escaped comma
+func splitMacroArgs(text string) []string {
+	args := make([]string, 0)
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && text[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case text[i] == ',':
+			args = append(args, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return append(args, b.String())
+}
+
+func (s *parser) ParseInlineMacro(d *Document, line string, i int) (*Macro, int) {
+	if line[i] != '{' {
+		return nil, 0
+	}
+	match := macroRegexp.FindStringSubmatchIndex(line[i:])
+	if match == nil {
+		return nil, 0
+	}
+	n := &Macro{Key: strings.ToLower(line[i+match[2] : i+match[3]])}
+	if match[4] >= 0 {
+		n.Args = splitMacroArgs(strings.Join(strings.Fields(line[i+match[4]:i+match[5]]), " "))
+	}
+	return n, match[1]
+}
+
+// macroExpander expands the macros of a document, which holds the state
+// of {{{n}}} counters
+type macroExpander struct {
+	*parser
+	counters map[string]int
+}
+
+func (s *macroExpander) expand(d *Document, nodes []Node, heading *Heading, depth int) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Heading:
+			s.expand(d, n.Title, n, depth)
+			s.expand(d, n.Children, n, depth)
+			continue
+		case *Include:
+			s.inInclude(d, n, func() { s.expand(d, n.Children, heading, depth) })
+			continue
+		case *Macro:
+			pos := n.Pos().Start
+			if depth >= maxMacroDepth {
+				d.diagnose(SeverityWarning, pos, "macro {{{%s}}} is expanded recursively", n.Key)
+				continue
+			}
+			text, ok := s.macro(d, heading, n)
+			if !ok {
+				d.diagnose(SeverityWarning, pos, "undefined macro {{{%s}}}", n.Key)
+				continue
+			}
+			s.seek(pos.Line-1, pos.Column-1)
+			n.Children = s.ParseAllInline(d, text, false)
+			s.expand(d, n.Children, heading, depth+1)
+			continue
+		}
+		for _, children := range childNodes(node) {
+			s.expand(d, *children, heading, depth)
+		}
+	}
+}
+
+func (s *macroExpander) macro(d *Document, heading *Heading, n *Macro) (string, bool) {
+	if fn, ok := d.Macros[n.Key]; ok {
+		return fn(d, heading, n.Args), true
+	}
+	arg := func(i int) string {
+		if i < len(n.Args) {
+			return strings.TrimSpace(n.Args[i])
+		}
+		return ""
+	}
+	switch n.Key {
+	case "title", "author", "email":
+		return d.Get(n.Key), true
+	case "keyword":
+		return d.Get(arg(0)), true
+	case "date":
+		date := d.Get("DATE")
+		if format := arg(0); format != "" {
+			if ts, _, err := s.parseTimestamp(d, strings.TrimSpace(date)); ts != nil && err == nil {
+				return strftime(format, ts.Time), true
+			}
+		}
+		return date, true
+	case "property":
+		if heading == nil {
+			v, _ := d.Property(arg(0))
+			return v, true
+		}
+		v, _ := heading.Property(arg(0), false)
+		return v, true
+	case "n":
+		// {{{n(name,action)}}}, action "-" keeps the counter, a number
+		// resets the counter, otherwise increments it
+		name, action := arg(0), arg(1)
+		if v, err := strconv.Atoi(action); err == nil {
+			s.counters[name] = v
+		} else if action != "-" {
+			s.counters[name]++
+		}
+		return strconv.Itoa(s.counters[name]), true
+	}
+	return "", false
+}
+
+// expandMacros expands all macros after the document is parsed, so
+// #+MACRO can be defined anywhere
+func (s *parser) expandMacros(d *Document, nodes []Node) {
+	e := &macroExpander{parser: s, counters: make(map[string]int)}
+	e.expand(d, nodes, nil, 0)
+}
+
+var strftimeLayouts = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'p': "PM",
+	'a': "Mon",
+	'A': "Monday",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'Z': "MST",
+	'z': "-0700",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+	'R': "15:04",
+	'D': "01/02/06",
+}
+
+// strftime formats t with format of format-time-string
+func strftime(format string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch c := format[i]; c {
+		case '%':
+			b.WriteByte('%')
+		case 'j':
+			b.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		default:
+			if layout, ok := strftimeLayouts[c]; ok {
+				b.WriteString(t.Format(layout))
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
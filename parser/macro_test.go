@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMacro(t *testing.T) {
+	expand := func(text string) string {
+		d := newDocument()
+		d.SetMacro("upper", func(d *Document, heading *Heading, args []string) string {
+			return strings.ToUpper(strings.Join(args, " "))
+		})
+		nodes := ParseFromText(d, text)
+		var b strings.Builder
+		Walk(nodes[len(nodes)-1:], func(node Node) bool {
+			if n, ok := node.(*InlineText); ok {
+				b.WriteString(n.Content)
+			}
+			return true
+		})
+		return b.String()
+	}
+	assert.Equal(t, "Hello world and a, b", expand("#+MACRO: greet Hello $1 and $2\n{{{greet(world,a\\, b)}}}"))
+	assert.Equal(t, "Title: Org", expand("#+TITLE: Org\nTitle: {{{title}}}"))
+	assert.Equal(t, "Org", expand("#+SUBTITLE: Org\n{{{keyword(subtitle)}}}"))
+	assert.Equal(t, "2022/03/04", expand("#+DATE: <2022-03-04 Fri>\n{{{date(%Y/%m/%d)}}}"))
+	assert.Equal(t, "1 2 2 5 6", expand("{{{n}}} {{{n}}} {{{n(,-)}}} {{{n(,5)}}} {{{n}}}"))
+	assert.Equal(t, "GO", expand("{{{upper(go)}}}"))
+	assert.Equal(t, "value", expand("* {{{property(KEY)}}}\n:PROPERTIES:\n:KEY: value\n:END:"))
+
+	d := newDocument()
+	nodes := ParseFromText(d, "#+MACRO: loop {{{loop}}}\n{{{loop}}} {{{undefined}}}")
+	macro := nodes[1].(*Paragragh).Children[0].(*Macro)
+	assert.Equal(t, "loop", macro.Key)
+	assert.Equal(t, Span{Position{2, 1, 25}, Position{2, 11, 35}}, macro.Pos())
+	assert.Len(t, d.Diagnostics, 2)
+}
@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// UnlimitedLevel is the depth of Toc and Headline if "toc" and "H" are
+// not set, so that headings of all levels are included.
+const UnlimitedLevel = math.MaxInt32
+
+// Options is the export options of #+OPTIONS, the defaults keep the
+// output of documents without #+OPTIONS, so headings are not numbered,
+// the depth of headings is unlimited and priorities are exported unlike
+// org-mode.
+type Options struct {
+	// toc:N, depth of the table of contents, 0 disables it
+	Toc int
+	// num:N, depth of the numbered headings, 0 disables numbering
+	Num int
+	// H:N, depth of the headings, deeper headings are exported as lists
+	Headline int
+	// ^:t, ^:{} or ^:nil, how subscript and superscript are parsed
+	SubSuperscript string
+	// todo:t, pri:t, tags:t or tags:not-in-toc
+	Todo      bool
+	Priority  bool
+	Tags      bool
+	TagsInToc bool
+	// f:t, footnotes
+	Footnotes bool
+	// tasks:t, tasks:nil, tasks:todo or tasks:("TODO" "WAIT"), which
+	// headings with TODO keyword are exported
+	Tasks string
+	// p:t, planning
+	Planning bool
+	// <:t, timestamps
+	Timestamp bool
+	// author:t, date:t, email:nil and title:t, which of #+AUTHOR, #+DATE,
+	// #+EMAIL and #+TITLE are exported
+	Author bool
+	Date   bool
+	Email  bool
+	Title  bool
+	// broken-links:nil, broken-links:t ignores broken links and
+	// broken-links:mark marks them
+	BrokenLinks string
+	// *:t, emphasis
+	Emphasis bool
+	// e:t, entities
+	Entities bool
+	// ::t, fixed width
+	FixedWidth bool
+	// d:t, drawers
+	Drawers bool
+	// stat:t, statistics cookies
+	Statistics bool
+}
+
+// DefaultOptions returns the options if there is no #+OPTIONS
+func DefaultOptions() Options {
+	return Options{
+		Toc:            UnlimitedLevel,
+		Headline:       UnlimitedLevel,
+		SubSuperscript: "t",
+		Todo:           true,
+		Priority:       true,
+		Tags:           true,
+		TagsInToc:      true,
+		Footnotes:      true,
+		Tasks:          "t",
+		Planning:       true,
+		Timestamp:      true,
+		Author:         true,
+		Date:           true,
+		Title:          true,
+		BrokenLinks:    "nil",
+		Emphasis:       true,
+		Entities:       true,
+		FixedWidth:     true,
+		Drawers:        true,
+		Statistics:     true,
+	}
+}
+
+// splitOptions splits the value of #+OPTIONS into key and value, a
+// value like ("TODO" "WAIT") may contain spaces
+func splitOptions(text string) [][2]string {
+	fields := strings.Fields(text)
+	options := make([][2]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		sep := strings.Index(field, ":")
+		if sep < 0 {
+			continue
+		}
+		// "::" is the key of fixed width option
+		if strings.HasPrefix(field, "::") {
+			sep = 1
+		}
+		key, value := field[:sep], field[sep+1:]
+		if strings.HasPrefix(value, "(") {
+			for !strings.HasSuffix(value, ")") && i+1 < len(fields) {
+				i++
+				value = value + " " + fields[i]
+			}
+		}
+		options = append(options, [2]string{key, value})
+	}
+	return options
+}
+
+// optionLevel parses the value of toc, num and H, "t" means -1
+func optionLevel(value string) int {
+	switch value {
+	case "nil":
+		return 0
+	case "t":
+		return -1
+	}
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+// Options returns the export options of #+OPTIONS, multiple #+OPTIONS
+// are merged.
+func (d *Document) Options() Options {
+	opts := DefaultOptions()
+
+	toc, num := opts.Toc, opts.Num
+	for _, option := range splitOptions(d.Get("OPTIONS")) {
+		key, value := option[0], option[1]
+		enabled := value != "nil"
+		switch key {
+		case "toc":
+			toc = optionLevel(value)
+		case "num":
+			num = optionLevel(value)
+		case "H":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.Headline = n
+			}
+		case "^":
+			opts.SubSuperscript = value
+		case "todo":
+			opts.Todo = enabled
+		case "pri":
+			opts.Priority = enabled
+		case "tags":
+			opts.Tags, opts.TagsInToc = enabled, enabled && value != "not-in-toc"
+		case "f":
+			opts.Footnotes = enabled
+		case "tasks":
+			opts.Tasks = value
+		case "p":
+			opts.Planning = enabled
+		case "<":
+			opts.Timestamp = enabled
+		case "author":
+			opts.Author = enabled
+		case "date":
+			opts.Date = enabled
+		case "email":
+			opts.Email = enabled
+		case "title":
+			opts.Title = enabled
+		case "broken-links":
+			opts.BrokenLinks = value
+		case "*":
+			opts.Emphasis = enabled
+		case "e":
+			opts.Entities = enabled
+		case ":":
+			opts.FixedWidth = enabled
+		case "d":
+			opts.Drawers = enabled
+		case "stat":
+			opts.Statistics = enabled
+		}
+	}
+	// toc:t and num:t use the depth of headings
+	if opts.Toc = toc; toc < 0 {
+		opts.Toc = opts.Headline
+	}
+	if opts.Num = num; num < 0 {
+		opts.Num = opts.Headline
+	}
+	return opts
+}
+
+// parseOptions returns the options of the document being parsed, which
+// are computed once after the settings are collected
+func (d *Document) parseOptions() Options {
+	if d.options == nil {
+		return d.Options()
+	}
+	return *d.options
+}
+
+// exportTask reports whether heading is exported by the tasks option
+func (opts Options) exportTask(heading *Heading) bool {
+	if heading.Keyword == "" {
+		return true
+	}
+	switch opts.Tasks {
+	case "t":
+		return true
+	case "nil":
+		return false
+	case "todo":
+		return !heading.IsDone()
+	}
+	for _, keyword := range strings.Fields(strings.Trim(opts.Tasks, "()")) {
+		if strings.Trim(keyword, `"`) == heading.Keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// exportTags returns the tags of key, or def if the keyword is not set
+func (d *Document) exportTags(key, def string) []string {
+	if v, ok := d.Keywords[key]; ok {
+		return strings.Fields(v)
+	}
+	return []string{def}
+}
+
+func hasTag(heading *Heading, tags []string) bool {
+	for _, tag := range heading.Tags {
+		for _, t := range tags {
+			if tag == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsTag reports whether any heading of the sections has one of tags
+func containsTag(sections []*Section, tags []string) bool {
+	for _, section := range sections {
+		if hasTag(section.Heading, tags) || containsTag(section.Children, tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// markExported marks which headings are exported after the document is
+// parsed, commented subtrees, subtrees with #+EXCLUDE_TAGS ("noexport" by
+// default) and tasks filtered by "tasks" of #+OPTIONS are not exported.
+// If any heading has #+SELECT_TAGS ("export" by default), only the
+// subtrees with the tags and their ancestors are exported.
+func (d *Document) markExported() {
+	if d.Sections == nil {
+		return
+	}
+	opts := d.parseOptions()
+	exclude := d.exportTags("EXCLUDE_TAGS", "noexport")
+	selects := d.exportTags("SELECT_TAGS", "export")
+	selected := containsTag(d.Sections.Children, selects)
+
+	var mark func([]*Section, bool, bool)
+	mark = func(sections []*Section, excluded, selectedParent bool) {
+		for _, section := range sections {
+			heading := section.Heading
+			sel := selectedParent || hasTag(heading, selects)
+			ex := excluded || heading.Commented || hasTag(heading, exclude) || !opts.exportTask(heading)
+			if selected && !sel && !containsTag(section.Children, selects) {
+				ex = true
+			}
+			heading.noExport = ex
+			mark(section.Children, ex, sel)
+		}
+	}
+	mark(d.Sections.Children, false, false)
+}
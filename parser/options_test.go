@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	d := newDocument()
+	ParseFromText(d, `#+OPTIONS: toc:2 num:t H:4 ^:{} pri:nil tags:not-in-toc
+#+OPTIONS: tasks:("TODO" "WAIT") broken-links:mark ::nil <:nil`)
+
+	opts := d.Options()
+	assert.Equal(t, 2, opts.Toc)
+	assert.Equal(t, 4, opts.Num)
+	assert.Equal(t, 4, opts.Headline)
+	assert.Equal(t, "{}", opts.SubSuperscript)
+	assert.False(t, opts.Priority)
+	assert.True(t, opts.Tags)
+	assert.False(t, opts.TagsInToc)
+	assert.Equal(t, `("TODO" "WAIT")`, opts.Tasks)
+	assert.Equal(t, "mark", opts.BrokenLinks)
+	assert.False(t, opts.FixedWidth)
+	assert.False(t, opts.Timestamp)
+	assert.True(t, opts.Todo)
+
+	assert.Equal(t, DefaultOptions(), newDocument().Options())
+	assert.Equal(t, UnlimitedLevel, DefaultOptions().Headline)
+	assert.Equal(t, UnlimitedLevel, DefaultOptions().Toc)
+}
+
+func TestExported(t *testing.T) {
+	exported := func(text string) []string {
+		d := newDocument()
+		titles := make([]string, 0)
+		Walk(ParseFromText(d, text), func(node Node) bool {
+			if heading, ok := node.(*Heading); ok && heading.IsExported() {
+				titles = append(titles, nodesText(heading.Title))
+			}
+			return true
+		})
+		return titles
+	}
+	assert.Equal(t, []string{"A", "C"}, exported("* A\n** B :noexport:\n*** B1\n* C\n* COMMENT D"))
+	assert.Equal(t, []string{"A", "C"}, exported("#+EXCLUDE_TAGS: private\n* A\n** B :private:\n* C"))
+	assert.Equal(t, []string{"A", "A2", "A21", "C"}, exported("* A\n** A1\n** A2 :export:\n*** A21\n* B\n* C :export:"))
+	assert.Equal(t, []string{"A", "C"}, exported("#+OPTIONS: tasks:nil\n* A\n* TODO B\n** B1\n* C"))
+	assert.Equal(t, []string{"A", "B"}, exported("#+OPTIONS: tasks:todo\n* A\n* TODO B\n* DONE C"))
+	assert.Equal(t, []string{"B", "C"}, exported("#+OPTIONS: tasks:(\"DONE\")\n* TODO A\n* DONE B\n* C"))
+}
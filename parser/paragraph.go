@@ -6,21 +6,24 @@ import (
 )
 
 const (
-	HrName        = "Hr"
-	FootnoteName  = "Footnote"
-	BlanklineName = "Blankline"
-	ParagraghName = "Paragragh"
+	HrName         = "Hr"
+	FootnoteName   = "Footnote"
+	BlanklineName  = "Blankline"
+	ParagraghName  = "Paragragh"
+	FixedWidthName = "FixedWidth"
 )
 
 var (
-	hrRegexp        = regexp.MustCompile(`^\s*\-{5,}\s*`)
-	footnoteRegexp  = regexp.MustCompile(`^\[fn:([\w-]*?)\]\s+(.*)$`)
-	blanklineRegexp = regexp.MustCompile(`^(\s*)(?:\r?\n|$)`)
-	plainTextRegexp = regexp.MustCompile(`^(\s*)(.*)`)
-	attributeRegexp = regexp.MustCompile(`(?:^|\s+)(:[-\w]+)\s+(.*)$`)
+	hrRegexp         = regexp.MustCompile(`^\s*\-{5,}\s*`)
+	footnoteRegexp   = regexp.MustCompile(`^\[fn:([\w-]*?)\]\s+(.*)$`)
+	blanklineRegexp  = regexp.MustCompile(`^(\s*)(?:\r?\n|$)`)
+	plainTextRegexp  = regexp.MustCompile(`^(\s*)(.*)`)
+	fixedWidthRegexp = regexp.MustCompile(`^(\s*):(?: (.*)|$)`)
 )
 
 type Footnote struct {
+	Span
+
 	Label      string
 	Inline     bool
 	Definition []Node
@@ -31,6 +34,8 @@ func (Footnote) Name() string {
 }
 
 type Blankline struct {
+	Span
+
 	Count int
 }
 
@@ -39,19 +44,82 @@ func (Blankline) Name() string {
 }
 
 type Paragragh struct {
-	Children []Node
+	Span
+
+	Affiliated *Affiliated
+	Children   []Node
 }
 
 func (Paragragh) Name() string {
 	return ParagraghName
 }
 
-type Hr struct{}
+// Link returns the link if the paragraph contains only one link
+func (s *Paragragh) Link() *InlineLink {
+	var link *InlineLink
+	for _, child := range s.Children {
+		switch n := child.(type) {
+		case *InlineLink:
+			if link != nil {
+				return nil
+			}
+			link = n
+		case *InlineLineBreak:
+		case *InlineText:
+			if strings.TrimSpace(n.Content) != "" {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return link
+}
+
+type Hr struct {
+	Span
+}
 
 func (Hr) Name() string {
 	return HrName
 }
 
+// FixedWidth is consecutive lines starting with ": ", the content is
+// kept literally. Level is the indentation of the first line and Indents
+// is the indentation of each line.
+type FixedWidth struct {
+	Span
+
+	Level   int
+	Indents []int
+	Content string
+}
+
+func (FixedWidth) Name() string {
+	return FixedWidthName
+}
+
+func (s *parser) ParseFixedWidth(d *Document, lines []string) (*FixedWidth, int) {
+	contents, indents := make([]string, 0), make([]int, 0)
+	for _, line := range lines {
+		m := fixedWidthRegexp.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+		contents = append(contents, m[2])
+		indents = append(indents, lineIndent(line))
+	}
+	if len(contents) == 0 {
+		return nil, 0
+	}
+	b := &FixedWidth{
+		Level:   indents[0],
+		Indents: indents,
+		Content: strings.Join(contents, "\n"),
+	}
+	return b, len(contents)
+}
+
 func (s *parser) ParseHr(d *Document, lines []string) (*Hr, int) {
 	match := hrRegexp.FindStringSubmatch(lines[0])
 	if match == nil || len(match) == 0 {
@@ -69,7 +137,7 @@ func (s *parser) ParseBlankLine(d *Document, lines []string) (*Blankline, int) {
 		idx++
 	}
 	if idx > 0 {
-		return &Blankline{idx}, idx
+		return &Blankline{Count: idx}, idx
 	}
 	return nil, 0
 }
@@ -87,6 +155,7 @@ func (s *parser) ParseFootnote(d *Document, lines []string) (*Footnote, int) {
 		}
 		idx++
 	}
+	s.seek(s.line, s.column+len(lines[0])-len(match[2]))
 	fn := &Footnote{
 		Label:      match[1],
 		Definition: s.ParseAll(d, append([]string{match[2]}, lines[1:idx]...), false),
@@ -95,12 +164,35 @@ func (s *parser) ParseFootnote(d *Document, lines []string) (*Footnote, int) {
 }
 
 func (s *parser) ParseParagragh(d *Document, lines []string) (*Paragragh, Node, int) {
+	line, column := s.line, s.column
 	idx, end := 1, len(lines)
 	for idx < end {
+		// affiliated keywords belong to the next element
+		if affiliatedRegexp.MatchString(lines[idx]) {
+			break
+		}
+		s.seekLine(line, column, idx)
 		if next, n := s.Parse(d, lines[idx:]); next != nil {
-			return &Paragragh{s.ParseAllInline(d, strings.Join(lines[:idx], "\n"), false)}, next, idx + n
+			return s.paragraph(d, line, column, lines[:idx]), next, idx + n
 		}
 		idx++
 	}
-	return &Paragragh{s.ParseAllInline(d, strings.Join(lines[:idx], "\n"), false)}, nil, idx
+	return s.paragraph(d, line, column, lines[:idx]), nil, idx
+}
+
+func (s *parser) paragraph(d *Document, line, column int, lines []string) *Paragragh {
+	for i := range lines {
+		s.seekLine(line, column, i)
+		if m := endBlockRegexp.FindStringSubmatch(lines[i]); m != nil {
+			s.warn(d, s.line, s.column, "#+END_%s without #+BEGIN_%[1]s", strings.ToUpper(m[2]))
+		} else if endDrawerRegexp.MatchString(lines[i]) {
+			s.warn(d, s.line, s.column, ":END: without drawer")
+		}
+	}
+	text := strings.Join(lines, "\n")
+
+	s.seek(line, column)
+	p := &Paragragh{Children: s.ParseAllInline(d, text, false)}
+	s.setPos(p, line, column, text)
+	return p
 }
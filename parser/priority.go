@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Priorities is the range of priorities, which is set by
+// "#+PRIORITIES: highest lowest default", such as "A C B" or "1 9 5".
+type Priorities struct {
+	Highest string
+	Lowest  string
+	Default string
+}
+
+var defaultPriorities = Priorities{Highest: "A", Lowest: "C", Default: "B"}
+
+// priorityValue returns the value of priority for comparison, a higher
+// priority has a smaller value, -1 means priority is invalid.
+func priorityValue(priority string) int {
+	if n, err := strconv.Atoi(priority); err == nil && n >= 0 {
+		return n
+	}
+	if len(priority) == 1 && priority[0] >= 'A' && priority[0] <= 'Z' {
+		return int(priority[0])
+	}
+	return -1
+}
+
+// Priorities returns the priorities of #+PRIORITIES, or A C B by default
+func (d *Document) Priorities() Priorities {
+	v := strings.Fields(d.Get("PRIORITIES"))
+	if len(v) < 2 {
+		return defaultPriorities
+	}
+	p := Priorities{Highest: v[0], Lowest: v[1]}
+	high, low := priorityValue(p.Highest), priorityValue(p.Lowest)
+	if high < 0 || low < high {
+		return defaultPriorities
+	}
+	if len(v) > 2 {
+		p.Default = v[2]
+	}
+	if value := priorityValue(p.Default); value < high || value > low {
+		// the middle of the range is used like org-mode
+		if _, err := strconv.Atoi(p.Highest); err == nil {
+			p.Default = strconv.Itoa((high + low) / 2)
+		} else {
+			p.Default = string(rune((high + low) / 2))
+		}
+	}
+	return p
+}
+
+// Contains reports whether priority is in the range of p
+func (p Priorities) Contains(priority string) bool {
+	value := priorityValue(priority)
+	return value >= 0 && value >= priorityValue(p.Highest) && value <= priorityValue(p.Lowest)
+}
+
+// EffectivePriority returns the priority of heading, or the default
+// priority if the heading has no priority cookie
+func (s *Heading) EffectivePriority() string {
+	if s.Priority != "" {
+		return s.Priority
+	}
+	return s.defaultPriority
+}
+
+// PriorityValue returns the value of the effective priority for sorting,
+// a higher priority has a smaller value.
+func (s *Heading) PriorityValue() int {
+	return priorityValue(s.EffectivePriority())
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	headings := func(d *Document, text string) []*Heading {
+		hs := make([]*Heading, 0)
+		for _, node := range ParseFromText(d, text) {
+			if heading, ok := node.(*Heading); ok {
+				hs = append(hs, heading)
+			}
+		}
+		return hs
+	}
+
+	d := newDocument()
+	hs := headings(d, "* [#D] Low\n* No priority\n* [#A] High\n#+PRIORITIES: A E C")
+	assert.Equal(t, Priorities{"A", "E", "C"}, d.Priorities())
+	assert.Equal(t, "D", hs[0].Priority)
+	assert.Equal(t, "No priority", nodesText(hs[1].Title))
+	assert.Equal(t, "C", hs[1].EffectivePriority())
+	sort.Slice(hs, func(i, j int) bool {
+		return hs[i].PriorityValue() < hs[j].PriorityValue()
+	})
+	assert.Equal(t, []string{"A", "", "D"}, []string{hs[0].Priority, hs[1].Priority, hs[2].Priority})
+	assert.Empty(t, d.Diagnostics)
+
+	d = newDocument()
+	hs = headings(d, "#+PRIORITIES: 1 20\n* [#10] Ten\n* [#9] Nine\n* [#30] Out\n* Default")
+	assert.Equal(t, Priorities{"1", "20", "10"}, d.Priorities())
+	assert.True(t, hs[1].PriorityValue() < hs[0].PriorityValue())
+	assert.Equal(t, "10", hs[3].EffectivePriority())
+	assert.Len(t, d.Diagnostics, 1)
+	assert.Equal(t, Position{4, 3, 45}, d.Diagnostics[0].Pos)
+
+	d = newDocument()
+	hs = headings(d, "* [#B] Title")
+	assert.Equal(t, defaultPriorities, d.Priorities())
+	assert.Equal(t, "B", hs[0].Priority)
+}
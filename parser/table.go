@@ -19,16 +19,23 @@ var (
 )
 
 type Table struct {
-	Children []Node
+	Span
+
+	Affiliated *Affiliated
+	Children   []Node
 }
 
 type TableRow struct {
+	Span
+
 	Children  []Node
 	Separator bool
 	Infos     []string
 }
 
 type TableColumn struct {
+	Span
+
 	Align    string
 	Width    int
 	IsHeader bool
@@ -49,32 +56,49 @@ func (TableColumn) Name() string {
 
 func (s *parser) ParseTableRow(d *Document, lines []string) (*TableRow, int) {
 	line := lines[0]
-	match := tableRowRegexp.FindStringSubmatch(line)
+	match := tableRowRegexp.FindStringSubmatchIndex(line)
 	if match == nil {
 		return nil, 0
 	}
+	ln, column := s.line, s.column
+
+	row := &TableRow{}
+	s.setPos(row, ln, column, line)
 	if tableSepRegexp.MatchString(line) {
-		return &TableRow{Separator: true}, 1
+		row.Separator = true
+		return row, 1
 	}
 
 	infos := make([]string, 0)
 	texts := make([]string, 0)
-	for _, text := range strings.FieldsFunc(match[2], func(r rune) bool { return r == '|' }) {
-		text = strings.TrimSpace(text)
-		texts = append(texts, text)
-		if m := tableAlignRegexp.FindStringSubmatch(text); m != nil {
-			infos = append(infos, m[1])
+	starts := make([]int, 0)
+	for i, j := match[4], match[4]; j <= len(line); j++ {
+		if j < len(line) && line[j] != '|' {
+			continue
+		}
+		if j > i {
+			text := strings.TrimSpace(line[i:j])
+			texts = append(texts, text)
+			starts = append(starts, i+strings.Index(line[i:j], text))
+			if m := tableAlignRegexp.FindStringSubmatch(text); m != nil {
+				infos = append(infos, m[1])
+			}
 		}
+		i = j + 1
 	}
 	// if not equal, infos is not infos, just tablecolumn
 	if len(infos) == len(texts) {
-		return &TableRow{Infos: infos}, 1
+		row.Infos = infos
+		return row, 1
 	}
 	children := make([]Node, len(texts))
 	for i, text := range texts {
+		s.seek(ln, column+starts[i])
 		children[i] = &TableColumn{Children: s.ParseAllInline(d, text, false)}
+		s.setPos(children[i], ln, column+starts[i], text)
 	}
-	return &TableRow{Children: children}, 1
+	row.Children = children
+	return row, 1
 }
 
 func (s *parser) ParseTable(d *Document, lines []string) (*Table, int) {
@@ -84,8 +108,10 @@ func (s *parser) ParseTable(d *Document, lines []string) (*Table, int) {
 		header int
 	)
 
+	line, column := s.line, s.column
 	idx, end := 0, len(lines)
 	for idx < end {
+		s.seekLine(line, column, idx)
 		row, rowIdx := s.ParseTableRow(d, lines[idx:])
 		if row == nil {
 			break
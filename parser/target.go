@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	TargetName      = "Target"
+	RadioTargetName = "RadioTarget"
+)
+
+var (
+	targetRegexp      = regexp.MustCompile(`^<<([^<>\s](?:[^<>\n]*[^<>\s])?)>>`)
+	radioTargetRegexp = regexp.MustCompile(`^<<<([^<>\s](?:[^<>\n]*[^<>\s])?)>>>`)
+)
+
+// Anchor is a node which internal links can point to
+type Anchor interface {
+	Node
+	Id() string
+}
+
+// Target is <<target>>, which is the destination of [[target]]
+type Target struct {
+	Span
+
+	Content string
+}
+
+func (Target) Name() string {
+	return TargetName
+}
+
+func (s *Target) Id() string {
+	return targetId(s.Content)
+}
+
+// RadioTarget is <<<target>>>, every occurrence of the target text in
+// the document is linked to it.
+type RadioTarget struct {
+	Span
+
+	Children []Node
+}
+
+func (RadioTarget) Name() string {
+	return RadioTargetName
+}
+
+func (s *RadioTarget) Id() string {
+	return targetId(nodesText(s.Children))
+}
+
+func targetId(text string) string {
+	return "target-" + strings.Join(strings.Fields(strings.ToLower(text)), "-")
+}
+
+// nodesText returns the plain text of inline nodes, with whitespace collapsed
+func nodesText(nodes []Node) string {
+	var b strings.Builder
+	Walk(nodes, func(node Node) bool {
+		if n, ok := node.(*InlineText); ok {
+			b.WriteString(n.Content)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func (s *parser) ParseInlineTarget(d *Document, line string, i int) (Node, int) {
+	if line[i] != '<' {
+		return nil, 0
+	}
+	if match := radioTargetRegexp.FindStringSubmatch(line[i:]); match != nil {
+		s.seek(s.line, s.column+3)
+		return &RadioTarget{Children: s.ParseAllInline(d, match[1], false)}, len(match[0])
+	}
+	if match := targetRegexp.FindStringSubmatch(line[i:]); match != nil {
+		return &Target{Content: match[1]}, len(match[0])
+	}
+	return nil, 0
+}
+
+// IsInternal reports whether link points into the document, which is
+// [[*heading]], [[#custom-id]] or [[target]], the link is broken if
+// Anchor is nil.
+func (s *InlineLink) IsInternal() bool {
+	if s.Protocol != "" || s.Type() != RegularLink {
+		return false
+	}
+	for _, prefix := range []string{"/", "./", "../", "~"} {
+		if strings.HasPrefix(s.URL, prefix) {
+			return false
+		}
+	}
+	return s.URL != ""
+}
+
+// linkResolver maps internal links to headings and targets
+type linkResolver struct {
+	*parser
+	headings  map[string]*Heading
+	customIds map[string]*Heading
+	targets   map[string]Anchor
+	radios    []*RadioTarget
+}
+
+func (s *linkResolver) collect(nodes []Node) {
+	Walk(nodes, func(node Node) bool {
+		switch n := node.(type) {
+		case *Heading:
+			if title := nodesText(n.Title); title != "" {
+				if _, ok := s.headings[title]; !ok {
+					s.headings[title] = n
+				}
+			}
+			if n.Properties != nil {
+				if id := n.Properties.Get("CUSTOM_ID"); id != "" {
+					s.customIds[id] = n
+				}
+			}
+		case *Target:
+			s.targets[strings.ToLower(n.Content)] = n
+		case *RadioTarget:
+			if text := nodesText(n.Children); text != "" {
+				s.targets[strings.ToLower(text)] = n
+				s.radios = append(s.radios, n)
+			}
+			return false
+		}
+		return true
+	})
+}
+
+func (s *linkResolver) resolve(d *Document, link *InlineLink) {
+	if !link.IsInternal() {
+		return
+	}
+	var anchor Anchor
+	switch {
+	case strings.HasPrefix(link.URL, "*"):
+		// the heading title is parsed, so that markup is compared as text
+		if heading, ok := s.headings[nodesText(s.ParseAllInline(d, link.URL[1:], false))]; ok {
+			anchor = heading
+		}
+	case strings.HasPrefix(link.URL, "#"):
+		if heading, ok := s.customIds[link.URL[1:]]; ok {
+			anchor = heading
+		}
+	default:
+		text := strings.Join(strings.Fields(link.URL), " ")
+		if target, ok := s.targets[strings.ToLower(text)]; ok {
+			anchor = target
+		} else if heading, ok := s.headings[text]; ok {
+			anchor = heading
+		}
+	}
+	if anchor == nil {
+		d.diagnose(SeverityWarning, link.Pos().Start, "link target %q not found", link.URL)
+		return
+	}
+	link.Anchor = anchor
+}
+
+// radioRegexp returns the regexp which matches the text of all radio
+// targets, longer targets are preferred
+func (s *linkResolver) radioRegexp() *regexp.Regexp {
+	texts := make([]string, 0, len(s.radios))
+	for _, radio := range s.radios {
+		words := strings.Fields(nodesText(radio.Children))
+		for i := range words {
+			words[i] = regexp.QuoteMeta(words[i])
+		}
+		texts = append(texts, strings.Join(words, `\s+`))
+	}
+	sort.SliceStable(texts, func(i, j int) bool {
+		return len(texts[i]) > len(texts[j])
+	})
+	return regexp.MustCompile(`(?i)` + strings.Join(texts, "|"))
+}
+
+// linkRadios splits the text nodes which contain the text of radio
+// targets, the text is replaced by links to the radio targets.
+func (s *linkResolver) linkRadios(d *Document, nodes []Node, re *regexp.Regexp) []Node {
+	result := make([]Node, 0, len(nodes))
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *RadioTarget:
+		case *Include:
+			s.inInclude(d, n, func() { n.Children = s.linkRadios(d, n.Children, re) })
+		case *InlineText:
+			if !n.Raw {
+				result = append(result, s.splitText(n, re)...)
+				continue
+			}
+		default:
+			for _, children := range childNodes(node) {
+				*children = s.linkRadios(d, *children, re)
+			}
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+func (s *linkResolver) splitText(n *InlineText, re *regexp.Regexp) []Node {
+	text := n.Content
+	line, column := n.Span.Start.Line-1, n.Span.Start.Column-1
+
+	nodes, last := make([]Node, 0), 0
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		// radio targets are only matched at word boundaries
+		if (m[0] > 0 && isWordChar(text[m[0]-1])) || (m[1] < len(text) && isWordChar(text[m[1]])) {
+			continue
+		}
+		match := text[m[0]:m[1]]
+		target := s.targets[strings.ToLower(strings.Join(strings.Fields(match), " "))]
+		if target == nil {
+			continue
+		}
+		if m[0] > last {
+			nodes = append(nodes, s.inlineText(line, column, text[last:m[0]]))
+		}
+		line, column = advance(line, column, text[last:m[0]])
+
+		link := &InlineLink{Protocol: "radio", URL: match, Desc: match, Anchor: target}
+		s.setPos(link, line, column, match)
+		nodes = append(nodes, link)
+		line, column = advance(line, column, match)
+		last = m[1]
+	}
+	if last == 0 {
+		return []Node{n}
+	}
+	if last < len(text) {
+		nodes = append(nodes, s.inlineText(line, column, text[last:]))
+	}
+	return nodes
+}
+
+// resolveLinks resolves the internal links after the document is parsed,
+// since a link can point to a heading or target after it.
+func (s *parser) resolveLinks(d *Document, nodes []Node) []Node {
+	r := &linkResolver{
+		parser:    s,
+		headings:  make(map[string]*Heading),
+		customIds: make(map[string]*Heading),
+		targets:   make(map[string]Anchor),
+	}
+	r.collect(nodes)
+	if len(r.radios) > 0 {
+		nodes = r.linkRadios(d, nodes, r.radioRegexp())
+	}
+	s.walk(d, nodes, func(node Node) bool {
+		if n, ok := node.(*InlineLink); ok {
+			r.resolve(d, n)
+		}
+		return true
+	})
+	return nodes
+}
@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarget(t *testing.T) {
+	d := newDocument()
+	nodes := ParseFromText(d, `* A *bold* title
+[[*A *bold* title]] [[missing]] [[./file.org]]
+<<<Go>>> and go, gopher`)
+
+	children := nodes[0].(*Heading).Children[0].(*Paragragh).Children
+	link := children[0].(*InlineLink)
+	assert.Equal(t, nodes[0], link.Anchor)
+	assert.Nil(t, children[2].(*InlineLink).Anchor)
+	assert.Nil(t, children[4].(*InlineLink).Anchor)
+
+	radio := children[6].(*RadioTarget)
+	assert.Equal(t, "target-go", radio.Id())
+	assert.Equal(t, Span{Position{3, 1, 64}, Position{3, 9, 72}}, radio.Pos())
+
+	text := children[7].(*InlineText)
+	assert.Equal(t, " and ", text.Content)
+	assert.Equal(t, Span{Position{3, 9, 72}, Position{3, 14, 77}}, text.Pos())
+	radioLink := children[8].(*InlineLink)
+	assert.Equal(t, radio, radioLink.Anchor)
+	assert.Equal(t, Span{Position{3, 14, 77}, Position{3, 16, 79}}, radioLink.Pos())
+	assert.Equal(t, ", gopher", children[9].(*InlineText).Content)
+
+	assert.Len(t, d.Diagnostics, 1)
+	assert.Equal(t, Position{2, 21, 37}, d.Diagnostics[0].Pos)
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timestampRegexp = regexp.MustCompile(`^([<\[])(\d{4}-\d{2}-\d{2})(?:\s+([^\s\d>\]+-]+))?(?:\s+(\d{1,2}:\d{2})(?:-(\d{1,2}:\d{2}))?)?((?:\s+(?:\+\+|\.\+|\+|--|-)\d+[hdwmy])*)\s*([>\]])`)
+	intervalRegexp  = regexp.MustCompile(`(\+\+|\.\+|\+|--|-)(\d+)([hdwmy])`)
+)
+
+// TimestampInterval is a repeater such as +1w, ++1d and .+1m, or a
+// warning delay such as -2d and --2d.
+type TimestampInterval struct {
+	Kind  string
+	Value int
+	Unit  byte
+}
+
+func (s *TimestampInterval) String() string {
+	return fmt.Sprintf("%s%d%c", s.Kind, s.Value, s.Unit)
+}
+
+// AddTo returns t plus n times the interval
+func (s *TimestampInterval) AddTo(t time.Time, n int) time.Time {
+	v := s.Value * n
+	switch s.Unit {
+	case 'h':
+		return t.Add(time.Duration(v) * time.Hour)
+	case 'd':
+		return t.AddDate(0, 0, v)
+	case 'w':
+		return t.AddDate(0, 0, 7*v)
+	case 'm':
+		return t.AddDate(0, v, 0)
+	default:
+		return t.AddDate(v, 0, 0)
+	}
+}
+
+type InlineTimestamp struct {
+	Span
+
+	// the original text
+	Content string
+	Active  bool
+	// IsDate is true if the timestamp has no time of day
+	IsDate bool
+	Time   time.Time
+	// End is the end of a date range <a>--<b> or a time span
+	// <2006-01-02 Mon 10:00-12:00>, zero otherwise
+	End      time.Time
+	Repeater *TimestampInterval
+	Warning  *TimestampInterval
+}
+
+func (InlineTimestamp) Name() string {
+	return InlineTimestampName
+}
+
+func (s *InlineTimestamp) IsRange() bool {
+	return !s.End.IsZero()
+}
+
+// Next returns the first occurrence of the timestamp after t, taking the
+// repeater into account. It returns false if there is no such occurrence.
+func (s *InlineTimestamp) Next(t time.Time) (time.Time, bool) {
+	if s.Time.After(t) {
+		return s.Time, true
+	}
+	r := s.Repeater
+	if r == nil || r.Value <= 0 {
+		return time.Time{}, false
+	}
+	if r.Kind == ".+" {
+		// shift from t instead of the timestamp itself
+		base := t
+		if r.Unit != 'h' {
+			base = time.Date(t.Year(), t.Month(), t.Day(), s.Time.Hour(), s.Time.Minute(), 0, 0, s.Time.Location())
+		}
+		next := r.AddTo(base, 1)
+		for !next.After(t) {
+			next = r.AddTo(next, 1)
+		}
+		return next, true
+	}
+	// estimate the number of repeats, months and years have different length
+	n := 1
+	if step := r.AddTo(s.Time, 1).Sub(s.Time); step > 0 {
+		if m := int(t.Sub(s.Time) / step); m > n {
+			n = m
+		}
+	}
+	for n > 1 && r.AddTo(s.Time, n-1).After(t) {
+		n--
+	}
+	for !r.AddTo(s.Time, n).After(t) {
+		n++
+	}
+	return r.AddTo(s.Time, n), true
+}
+
+func (s *parser) parseTime(d *Document, date, clock string) (time.Time, error) {
+	if clock == "" {
+		clock = "00:00"
+	}
+	return time.Parse(d.TimestampFormat, fmt.Sprintf("%s Mon %s", date, clock))
+}
+
+// parseTimestamp parses a timestamp without range
+func (s *parser) parseTimestamp(d *Document, text string) (*InlineTimestamp, int, error) {
+	m := timestampRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return nil, 0, nil
+	}
+	if (m[1] == "<") != (m[7] == ">") {
+		return nil, 0, nil
+	}
+	t, err := s.parseTime(d, m[2], m[4])
+	if err != nil {
+		return nil, 0, err
+	}
+	b := &InlineTimestamp{
+		Content: m[0],
+		Active:  m[1] == "<",
+		IsDate:  m[4] == "",
+		Time:    t,
+	}
+	if m[5] != "" {
+		if b.End, err = s.parseTime(d, m[2], m[5]); err != nil {
+			return nil, 0, err
+		}
+	}
+	for _, im := range intervalRegexp.FindAllStringSubmatch(m[6], -1) {
+		value, _ := strconv.Atoi(im[2])
+		interval := &TimestampInterval{Kind: im[1], Value: value, Unit: im[3][0]}
+		if strings.HasPrefix(im[1], "-") {
+			b.Warning = interval
+		} else {
+			b.Repeater = interval
+		}
+	}
+	return b, len(m[0]), nil
+}
+
+func (s *parser) ParseInlineTimestamp(d *Document, line string, i int) (*InlineTimestamp, int) {
+	if line[i] != '<' && line[i] != '[' {
+		return nil, 0
+	}
+	b, n, err := s.parseTimestamp(d, line[i:])
+	if err != nil {
+		s.warn(d, s.line, s.column, "bad timestamp %s", timestampRegexp.FindString(line[i:]))
+		return nil, 0
+	}
+	if b == nil {
+		return nil, 0
+	}
+	// date range such as <2006-01-02 Mon>--<2006-01-03 Tue>
+	if rest := line[i+n:]; strings.HasPrefix(rest, "--") && !b.IsRange() {
+		end, m, err := s.parseTimestamp(d, rest[2:])
+		if err == nil && end != nil && end.Active == b.Active && !end.IsRange() {
+			b.Content = line[i : i+n+2+m]
+			b.End = end.Time
+			return b, n + 2 + m
+		}
+	}
+	return b, n
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func parseTimestamp(text string) *InlineTimestamp {
+	d := newDocument()
+	nodes := ParseFromText(d, text)
+	if len(nodes) == 0 {
+		return nil
+	}
+	children := nodes[0].(*Paragragh).Children
+	if len(children) != 1 {
+		return nil
+	}
+	ts, _ := children[0].(*InlineTimestamp)
+	return ts
+}
+
+func TestTimestamp(t *testing.T) {
+	ts := parseTimestamp("<2026-10-17 Sat>")
+	assert.True(t, ts.Active)
+	assert.True(t, ts.IsDate)
+	assert.False(t, ts.IsRange())
+	assert.Equal(t, date(2026, 10, 17, 0, 0), ts.Time)
+
+	ts = parseTimestamp("[2026-10-17 Sat 9:05]")
+	assert.False(t, ts.Active)
+	assert.False(t, ts.IsDate)
+	assert.Equal(t, date(2026, 10, 17, 9, 5), ts.Time)
+
+	ts = parseTimestamp("<2026-10-17 Sat 10:00-12:30>")
+	assert.True(t, ts.IsRange())
+	assert.Equal(t, date(2026, 10, 17, 12, 30), ts.End)
+
+	ts = parseTimestamp("<2026-10-17 Sat>--<2026-10-20 Tue>")
+	assert.Equal(t, "<2026-10-17 Sat>--<2026-10-20 Tue>", ts.Content)
+	assert.Equal(t, date(2026, 10, 20, 0, 0), ts.End)
+
+	ts = parseTimestamp("<2026-10-17 Sat 10:00 .+2w -3d>")
+	assert.Equal(t, &TimestampInterval{".+", 2, 'w'}, ts.Repeater)
+	assert.Equal(t, &TimestampInterval{"-", 3, 'd'}, ts.Warning)
+
+	ts = parseTimestamp("<2026-10-17 周六 ++1m --1d>")
+	assert.Equal(t, "++1m", ts.Repeater.String())
+	assert.Equal(t, "--1d", ts.Warning.String())
+
+	assert.Nil(t, parseTimestamp("<2026-10-17 Sat]"))
+	assert.Nil(t, parseTimestamp("[2026-10-17 Sat]--<2026-10-20 Tue>"))
+}
+
+func TestTimestampNext(t *testing.T) {
+	now := date(2026, 10, 17, 8, 0)
+
+	tests := []struct {
+		text   string
+		next   time.Time
+		exists bool
+	}{
+		{"<2026-10-20 Tue>", date(2026, 10, 20, 0, 0), true},
+		{"<2026-10-10 Sat>", time.Time{}, false},
+		{"<2026-10-03 Sat 09:00 +1w>", date(2026, 10, 17, 9, 0), true},
+		{"<2026-10-10 Sat 07:00 +1w>", date(2026, 10, 24, 7, 0), true},
+		{"<2026-10-17 Sat 09:00 +1d>", date(2026, 10, 17, 9, 0), true},
+		{"<2026-10-16 Fri 09:00 ++1d>", date(2026, 10, 17, 9, 0), true},
+		{"<2026-01-31 Sat +1m>", date(2026, 10, 31, 0, 0), true},
+		{"<2020-02-29 Sat +1y>", date(2027, 3, 1, 0, 0), true},
+		{"<2026-10-17 Sat 07:00 +2h>", date(2026, 10, 17, 9, 0), true},
+		{"<2026-09-01 Tue 10:00 .+3d>", date(2026, 10, 20, 10, 0), true},
+	}
+	for _, test := range tests {
+		next, ok := parseTimestamp(test.text).Next(now)
+		assert.Equal(t, test.exists, ok, test.text)
+		assert.Equal(t, test.next, next, test.text)
+	}
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	todoKeywordRegexp = regexp.MustCompile(`^([^\s()|]+)(?:\(([^\s@!/)]?)([@!]?(?:/[@!])?)\))?$`)
+)
+
+// TodoKeyword is a keyword of TODO sequence, such as WAIT(w@/!), Key
+// is the fast access key and Log is how state changes are logged.
+type TodoKeyword struct {
+	Name string
+	Key  string
+	Log  string
+	Done bool
+}
+
+// TodoSequence is the keywords of #+TODO, #+SEQ_TODO or #+TYP_TODO, the
+// keywords after "|" are done states, or the last one if no "|".
+type TodoSequence struct {
+	Type     string
+	Keywords []TodoKeyword
+}
+
+// parseTodoSequence parses "TODO(t) WAIT(w@/!) | DONE(d)"
+func parseTodoSequence(typ, text string) TodoSequence {
+	seq := TodoSequence{Type: strings.ToUpper(typ)}
+	done := -1
+	for _, field := range strings.Fields(text) {
+		if field == "|" {
+			if done < 0 {
+				done = len(seq.Keywords)
+			}
+			continue
+		}
+		keyword := TodoKeyword{Name: field}
+		if match := todoKeywordRegexp.FindStringSubmatch(field); match != nil {
+			keyword = TodoKeyword{Name: match[1], Key: match[2], Log: match[3]}
+		}
+		seq.Keywords = append(seq.Keywords, keyword)
+	}
+	if done < 0 {
+		done = len(seq.Keywords) - 1
+	}
+	for i := range seq.Keywords {
+		seq.Keywords[i].Done = i >= done
+	}
+	return seq
+}
+
+// AddTodoSequence adds a TODO sequence like #+TODO, typ is one of TODO,
+// SEQ_TODO and TYP_TODO
+func (d *Document) AddTodoSequence(typ, text string) {
+	if seq := parseTodoSequence(typ, text); len(seq.Keywords) > 0 {
+		d.TodoSequences = append(d.TodoSequences, seq)
+	}
+}
+
+// todoSequences returns the TODO sequences of the document, the TODO
+// keyword is used if there are no sequences.
+func (d *Document) todoSequences() []TodoSequence {
+	if len(d.TodoSequences) > 0 {
+		return d.TodoSequences
+	}
+	if todo := d.Get("TODO"); todo != "" {
+		return []TodoSequence{parseTodoSequence("TODO", todo)}
+	}
+	return nil
+}
+
+// TodoKeyword returns the definition of TODO keyword name
+func (d *Document) TodoKeyword(name string) (TodoKeyword, bool) {
+	for _, seq := range d.todoSequences() {
+		for _, keyword := range seq.Keywords {
+			if keyword.Name == name {
+				return keyword, true
+			}
+		}
+	}
+	return TodoKeyword{}, false
+}
+
+// isDone reports whether keyword is a done state of the TODO sequences
+func (d *Document) isDone(keyword string) bool {
+	todo, ok := d.TodoKeyword(keyword)
+	return ok && todo.Done
+}
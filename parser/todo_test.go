@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoSequence(t *testing.T) {
+	d := newDocument()
+	nodes := ParseFromText(d, `* WAIT Before the settings
+#+TODO: TODO(t) WAIT(w@/!) | DONE(d!) CANCELED(c@)
+#+TYP_TODO: Fred Sara | FIXED
+* TODO Write
+* DONE Written
+* Sara Review
+* FIXED Bug
+* NEXT Not a keyword`)
+
+	assert.Len(t, d.TodoSequences, 2)
+	wait, ok := d.TodoKeyword("WAIT")
+	assert.True(t, ok)
+	assert.Equal(t, TodoKeyword{Name: "WAIT", Key: "w", Log: "@/!"}, wait)
+	assert.Equal(t, "TYP_TODO", d.TodoSequences[1].Type)
+
+	expect := []struct {
+		keyword string
+		done    bool
+	}{{"WAIT", false}, {"TODO", false}, {"DONE", true}, {"Sara", false}, {"FIXED", true}, {"", false}}
+	headings := make([]*Heading, 0)
+	for _, node := range nodes {
+		if heading, ok := node.(*Heading); ok {
+			headings = append(headings, heading)
+		}
+	}
+	assert.Len(t, headings, len(expect))
+	for i, heading := range headings {
+		assert.Equal(t, expect[i].keyword, heading.Keyword)
+		assert.Equal(t, expect[i].done, heading.IsDone())
+	}
+
+	// without "|" the last keyword is the done state
+	seq := parseTodoSequence("SEQ_TODO", "OPEN CLOSED")
+	assert.False(t, seq.Keywords[0].Done)
+	assert.True(t, seq.Keywords[1].Done)
+}
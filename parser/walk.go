@@ -0,0 +1,66 @@
+package parser
+
+// childNodes returns the child lists of node, the lists are returned by
+// pointer so that a pass can rewrite them
+func childNodes(node Node) []*[]Node {
+	switch n := node.(type) {
+	case *Heading:
+		return []*[]Node{&n.Title, &n.Children}
+	case *Paragragh:
+		return append(affiliatedNodes(n.Affiliated), &n.Children)
+	case *List:
+		return append(affiliatedNodes(n.Affiliated), &n.Children)
+	case *ListItem:
+		return []*[]Node{&n.Title, &n.Children}
+	case *DescriptiveItem:
+		return []*[]Node{&n.Title, &n.Children}
+	case *Table:
+		return append(affiliatedNodes(n.Affiliated), &n.Children)
+	case *TableRow:
+		return []*[]Node{&n.Children}
+	case *TableColumn:
+		return []*[]Node{&n.Children}
+	case *Block:
+		return append(affiliatedNodes(n.Affiliated), &n.Children)
+	case *BlockResult:
+		return []*[]Node{&n.Children}
+	case *Drawer:
+		return []*[]Node{&n.Children}
+	case *Footnote:
+		return []*[]Node{&n.Definition}
+	case *InlineEmphasis:
+		return []*[]Node{&n.Children}
+	case *Subscript:
+		return []*[]Node{&n.Children}
+	case *Superscript:
+		return []*[]Node{&n.Children}
+	case *Macro:
+		return []*[]Node{&n.Children}
+	case *RadioTarget:
+		return []*[]Node{&n.Children}
+	case *Include:
+		return []*[]Node{&n.Children}
+	}
+	return nil
+}
+
+// affiliatedNodes returns the captions of aff, which are parsed objects
+func affiliatedNodes(aff *Affiliated) []*[]Node {
+	if aff == nil {
+		return nil
+	}
+	return []*[]Node{&aff.Caption, &aff.ShortCaption}
+}
+
+// Walk traverses nodes in depth-first order, the children of a node are
+// skipped if fn returns false
+func Walk(nodes []Node, fn func(Node) bool) {
+	for _, node := range nodes {
+		if !fn(node) {
+			continue
+		}
+		for _, children := range childNodes(node) {
+			Walk(*children, fn)
+		}
+	}
+}
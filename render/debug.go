@@ -15,6 +15,18 @@ type Renderer interface {
 	RenderInlinePercent(*parser.InlinePercent) string
 	RenderInlineEmphasis(*parser.InlineEmphasis) string
 	RenderInlineTimestamp(*parser.InlineTimestamp) string
+	RenderLatexFragment(*parser.LatexFragment) string
+	RenderEntity(*parser.Entity) string
+	RenderSubscript(*parser.Subscript) string
+	RenderSuperscript(*parser.Superscript) string
+	RenderMacro(*parser.Macro) string
+	RenderInlineSrc(*parser.InlineSrc) string
+	RenderInlineCall(*parser.InlineCall) string
+	RenderTarget(*parser.Target) string
+	RenderRadioTarget(*parser.RadioTarget) string
+	RenderExportSnippet(*parser.ExportSnippet) string
+	RenderExportKeyword(*parser.ExportKeyword) string
+	RenderInclude(*parser.Include) string
 	RenderInlineLineBreak(*parser.InlineLineBreak) string
 	RenderInlineBackSlash(*parser.InlineBackSlash) string
 	RenderSection(*parser.Section) string
@@ -23,13 +35,17 @@ type Renderer interface {
 	RenderBlankline(*parser.Blankline) string
 	RenderList(*parser.List) string
 	RenderListItem(*parser.ListItem) string
+	RenderDescriptiveItem(*parser.DescriptiveItem) string
 	RenderTable(*parser.Table) string
 	RenderTableRow(*parser.TableRow) string
 	RenderTableColumn(*parser.TableColumn) string
 	RenderBlock(*parser.Block) string
 	RenderBlockResult(*parser.BlockResult) string
+	RenderComment(*parser.Comment) string
 	RenderDrawer(*parser.Drawer) string
 	RenderHr(*parser.Hr) string
+	RenderFixedWidth(*parser.FixedWidth) string
+	RenderLatexEnvironment(*parser.LatexEnvironment) string
 	RenderFootnote(*parser.Footnote) string
 	RenderParagraph(*parser.Paragragh) string
 }
@@ -78,6 +94,8 @@ func RenderNode(r Renderer, n parser.Node) string {
 		return r.RenderInlinePercent(node)
 	case *parser.InlineEmphasis:
 		return r.RenderInlineEmphasis(node)
+	case *parser.InlineTimestamp:
+		return r.RenderInlineTimestamp(node)
 	case *parser.Section:
 		return r.RenderSection(node)
 	case *parser.Heading:
@@ -100,10 +118,42 @@ func RenderNode(r Renderer, n parser.Node) string {
 		return r.RenderList(node)
 	case *parser.ListItem:
 		return r.RenderListItem(node)
+	case *parser.DescriptiveItem:
+		return r.RenderDescriptiveItem(node)
 	case *parser.Drawer:
 		return r.RenderDrawer(node)
+	case *parser.Comment:
+		return r.RenderComment(node)
 	case *parser.Hr:
 		return r.RenderHr(node)
+	case *parser.FixedWidth:
+		return r.RenderFixedWidth(node)
+	case *parser.LatexFragment:
+		return r.RenderLatexFragment(node)
+	case *parser.Entity:
+		return r.RenderEntity(node)
+	case *parser.Subscript:
+		return r.RenderSubscript(node)
+	case *parser.Superscript:
+		return r.RenderSuperscript(node)
+	case *parser.Macro:
+		return r.RenderMacro(node)
+	case *parser.InlineSrc:
+		return r.RenderInlineSrc(node)
+	case *parser.InlineCall:
+		return r.RenderInlineCall(node)
+	case *parser.Target:
+		return r.RenderTarget(node)
+	case *parser.RadioTarget:
+		return r.RenderRadioTarget(node)
+	case *parser.ExportSnippet:
+		return r.RenderExportSnippet(node)
+	case *parser.ExportKeyword:
+		return r.RenderExportKeyword(node)
+	case *parser.Include:
+		return r.RenderInclude(node)
+	case *parser.LatexEnvironment:
+		return r.RenderLatexEnvironment(node)
 	case *parser.Footnote:
 		return r.RenderFootnote(node)
 	case *parser.Paragragh:
@@ -190,6 +240,66 @@ func (r *Debug) RenderBlockResult(n *parser.BlockResult) string {
 	return r.render(n.Name(), n.Children, "\n")
 }
 
+func (r *Debug) RenderLatexFragment(n *parser.LatexFragment) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderEntity(n *parser.Entity) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderSubscript(n *parser.Subscript) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderSuperscript(n *parser.Superscript) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderMacro(n *parser.Macro) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderInlineSrc(n *parser.InlineSrc) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderInlineCall(n *parser.InlineCall) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderTarget(n *parser.Target) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderRadioTarget(n *parser.RadioTarget) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderExportSnippet(n *parser.ExportSnippet) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderExportKeyword(n *parser.ExportKeyword) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderInclude(n *parser.Include) string {
+	return r.render(n.Name(), n.Children, "\n")
+}
+
+func (r *Debug) RenderLatexEnvironment(n *parser.LatexEnvironment) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderFixedWidth(n *parser.FixedWidth) string {
+	return n.Name()
+}
+
+func (r *Debug) RenderComment(n *parser.Comment) string {
+	return n.Name()
+}
+
 func (r *Debug) RenderDrawer(n *parser.Drawer) string {
 	return r.render(n.Name(), n.Children, "\n")
 }
@@ -198,6 +308,10 @@ func (r *Debug) RenderListItem(n *parser.ListItem) string {
 	return r.render(n.Name(), n.Children, "\n")
 }
 
+func (r *Debug) RenderDescriptiveItem(n *parser.DescriptiveItem) string {
+	return r.render(n.Name(), n.Children, "\n")
+}
+
 func (r *Debug) RenderList(n *parser.List) string {
 	return r.render(n.Name(), n.Children, "\n")
 }
@@ -5,20 +5,35 @@ import (
 	"net/url"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/honmaple/org-golang/parser"
 )
 
 type HTML struct {
-	Document           *parser.Document
-	Toc                bool
-	HeadingOffset      int
+	Document      *parser.Document
+	Toc           bool
+	HidePlanning  bool
+	HeadingOffset int
+	// Title renders #+TITLE before the content, and #+AUTHOR, #+DATE and
+	// #+EMAIL after it, each of them can be disabled by #+OPTIONS
+	Title bool
+	// TimestampFormat and DateFormat are go time layouts used to display
+	// timestamps with and without time of day, if empty, the original
+	// text is displayed
+	TimestampFormat    string
+	DateFormat         string
 	RenderNodeFunc     func(Renderer, parser.Node) string
 	RenderFootnoteFunc func(Renderer, []*parser.Footnote, map[string]bool) string
-
-	fnList []*parser.Footnote
-	fnUsed map[string]bool
+	// RenderLatexFunc converts the TeX of latex fragments and environments
+	// to html, such as server side KaTeX, if it's nil or returns an error,
+	// the TeX is kept in MathJax/KaTeX delimiters
+	RenderLatexFunc func(tex string, display bool) (string, error)
+
+	fnList  []*parser.Footnote
+	fnUsed  map[string]bool
+	options *parser.Options
 }
 
 var htmlEscaper = strings.NewReplacer(
@@ -26,10 +41,57 @@ var htmlEscaper = strings.NewReplacer(
 	`>`, "&gt;",
 )
 
+var attrEscaper = strings.NewReplacer(
+	`<`, "&lt;",
+	`>`, "&gt;",
+	`"`, "&quot;",
+)
+
 func htmlEscape(s string) string {
 	return htmlEscaper.Replace(s)
 }
 
+// affiliatedAttrs returns the html attributes of aff, #+NAME is used as id
+func affiliatedAttrs(aff *parser.Affiliated) map[string]string {
+	attrs := make(map[string]string)
+	for k, v := range aff.Attrs("html") {
+		attrs[k] = v
+	}
+	if aff != nil && aff.Name != "" && attrs["id"] == "" {
+		attrs["id"] = aff.Name
+	}
+	return attrs
+}
+
+// attributes formats attrs as html attributes, class is prepended to the class attribute
+func attributes(attrs map[string]string, class string) string {
+	if class != "" {
+		if c := attrs["class"]; c != "" {
+			class = class + " " + c
+		}
+		attrs["class"] = class
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf(` %s="%s"`, k, attrEscaper.Replace(attrs[k])))
+	}
+	return b.String()
+}
+
+// figure wraps content into a figure if aff has a caption
+func (r *HTML) figure(content string, aff *parser.Affiliated) string {
+	if aff == nil || len(aff.Caption) == 0 {
+		return content
+	}
+	return fmt.Sprintf("<figure>\n%[1]s\n<figcaption>%[2]s</figcaption>\n</figure>", content, r.RenderNodes(aff.Caption, ""))
+}
+
 // If def is true, use default RenderNode
 func (r *HTML) RenderNode(n parser.Node, def bool) string {
 	if def || r.RenderNodeFunc == nil {
@@ -38,32 +100,92 @@ func (r *HTML) RenderNode(n parser.Node, def bool) string {
 	return r.RenderNodeFunc(r, n)
 }
 
+// RenderNodes renders children, the consecutive headings deeper than "H"
+// of #+OPTIONS are rendered as the items of one list. The nodes rendered
+// as nothing, such as comments and keywords, are skipped, so that they
+// don't leave empty lines, but blank lines are kept.
 func (r *HTML) RenderNodes(children []parser.Node, sep string) string {
-	return RenderNodes(r, children, sep)
+	cs := make([]string, 0, len(children))
+	for i := 0; i < len(children); i++ {
+		if !r.isListHeading(children[i]) {
+			if c := r.RenderNode(children[i], false); c != "" || children[i].Name() == parser.BlanklineName {
+				cs = append(cs, c)
+			}
+			continue
+		}
+		items := make([]string, 0)
+		for ; i < len(children) && r.isListHeading(children[i]); i++ {
+			if item := r.RenderNode(children[i], false); item != "" {
+				items = append(items, item)
+			}
+		}
+		i--
+		if len(items) > 0 {
+			cs = append(cs, "<ul>\n"+strings.Join(items, "\n")+"\n</ul>")
+		}
+	}
+	return strings.Join(cs, sep)
+}
+
+func (r *HTML) isListHeading(n parser.Node) bool {
+	heading, ok := n.(*parser.Heading)
+	return ok && heading.Stars > r.opts().Headline
+}
+
+// opts returns the #+OPTIONS of the document
+func (r *HTML) opts() *parser.Options {
+	if r.options == nil {
+		opts := r.Document.Options()
+		r.options = &opts
+	}
+	return r.options
 }
 
 func (r *HTML) RenderInlineLink(n *parser.InlineLink) string {
-	rawURL := n.URL
-	if n.Protocol != "" && n.Protocol != "file" {
-		rawURL = n.Protocol + "://" + n.URL
+	return r.link(n, "")
+}
+
+func (r *HTML) link(n *parser.InlineLink, attrs string) string {
+	if n.Anchor != nil {
+		return r.internalLink(n, attrs)
 	}
+	if n.IsInternal() {
+		// broken-links:mark marks the broken link and broken-links:t ignores it
+		switch r.opts().BrokenLinks {
+		case "mark":
+			return fmt.Sprintf("[BROKEN LINK: %s]", htmlEscape(n.URL))
+		case "t":
+			return ""
+		}
+	}
+	rawURL, desc, typ := r.Document.ResolveLink(n)
 
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return ""
 	}
-	switch n.Type() {
+	switch typ {
 	case parser.ImageLink:
-		return fmt.Sprintf("<img src=\"%s\" alt=\"%s\"/>", rawURL, filepath.Base(parsedURL.Path))
+		return fmt.Sprintf("<img src=\"%s\" alt=\"%s\"%s/>", rawURL, filepath.Base(parsedURL.Path), attrs)
 	case parser.VideoLink:
-		return fmt.Sprintf("<video src=\"%s\">%s</video>", rawURL, filepath.Base(parsedURL.Path))
+		return fmt.Sprintf("<video src=\"%s\"%s>%s</video>", rawURL, attrs, filepath.Base(parsedURL.Path))
 	default:
-		desc := rawURL
-		if n.Desc != "" {
-			desc = n.Desc
+		return fmt.Sprintf("<a href=\"%s\"%s>%s</a>", rawURL, attrs, desc)
+	}
+}
+
+// internalLink renders the link to a heading or target in the document,
+// the heading title is the default description of heading links.
+func (r *HTML) internalLink(n *parser.InlineLink, attrs string) string {
+	desc := n.Desc
+	if desc == "" {
+		if heading, ok := n.Anchor.(*parser.Heading); ok {
+			desc = r.RenderNodes(heading.Title, "")
+		} else {
+			desc = htmlEscape(n.URL)
 		}
-		return fmt.Sprintf("<a href=\"%s\">%s</a>", rawURL, desc)
 	}
+	return fmt.Sprintf("<a href=\"#%s\"%s>%s</a>", n.Anchor.Id(), attrs, desc)
 }
 
 func (r *HTML) RenderInlineText(n *parser.InlineText) string {
@@ -75,6 +197,9 @@ func (r *HTML) RenderInlineText(n *parser.InlineText) string {
 
 func (r *HTML) RenderInlineEmphasis(n *parser.InlineEmphasis) string {
 	text := r.RenderNodes(n.Children, "")
+	if !r.opts().Emphasis {
+		return n.Marker + text + n.Marker
+	}
 	switch n.Marker {
 	case "=", "~", "`":
 		return fmt.Sprintf("<code>%s</code>", text)
@@ -102,40 +227,132 @@ func (r *HTML) RenderInlineLineBreak(n *parser.InlineLineBreak) string {
 	return strings.Repeat("\n", n.Count)
 }
 
+// timestampFormat returns the layout of n, which is empty if the original
+// text is displayed
+func (r *HTML) timestampFormat(n *parser.InlineTimestamp) string {
+	if n.IsDate && r.DateFormat != "" {
+		return r.DateFormat
+	}
+	return r.TimestampFormat
+}
+
 func (r *HTML) RenderInlineTimestamp(n *parser.InlineTimestamp) string {
-	return ""
+	if !r.opts().Timestamp {
+		return ""
+	}
+	return r.inlineTimestamp(n)
+}
+
+func (r *HTML) inlineTimestamp(n *parser.InlineTimestamp) string {
+	class := "timestamp active"
+	if !n.Active {
+		class = "timestamp inactive"
+	}
+	if n.IsRange() {
+		class = class + " range"
+	}
+	datetime := "2006-01-02T15:04"
+	if n.IsDate {
+		datetime = "2006-01-02"
+	}
+
+	text := htmlEscape(n.Content)
+	if format := r.timestampFormat(n); format != "" {
+		text = n.Time.Format(format)
+		if n.IsRange() {
+			text = text + "&ndash;" + n.End.Format(format)
+		}
+	}
+	return fmt.Sprintf("<time class=\"%s\" datetime=\"%s\">%s</time>", class, n.Time.Format(datetime), text)
 }
 
 func (r *HTML) RenderInlinePercent(n *parser.InlinePercent) string {
+	if !r.opts().Statistics {
+		return ""
+	}
 	return fmt.Sprintf("<code>[%s]</code>", n.Num)
 }
 
-func (r *HTML) heading(n *parser.Heading) string {
+func (r *HTML) heading(n *parser.Heading, toc bool) string {
 	var b strings.Builder
 
-	if n.Keyword != "" {
-		b.WriteString(fmt.Sprintf("<span class=\"todo\">%[1]s</span>", n.Keyword))
+	opts := r.opts()
+	if opts.Num >= n.Stars {
+		b.WriteString(fmt.Sprintf("<span class=\"section-number-%d\">%s</span> ", n.Stars+r.HeadingOffset, n.Index))
 	}
-	if n.Priority != "" {
-		b.WriteString(fmt.Sprintf("<span class=\"priority\">%[1]s</span>", n.Priority))
+	if n.Keyword != "" && opts.Todo {
+		class := "todo"
+		if n.IsDone() {
+			class = "done"
+		}
+		b.WriteString(fmt.Sprintf("<span class=\"%s %s\">%[2]s</span>", class, n.Keyword))
+	}
+	if n.Priority != "" && opts.Priority {
+		b.WriteString(fmt.Sprintf("<span class=\"priority priority-%[1]s\">%[1]s</span>", n.Priority))
 	}
 	b.WriteString(r.RenderNodes(n.Title, ""))
+	if !opts.Tags || (toc && !opts.TagsInToc) {
+		return b.String()
+	}
 	for _, tag := range n.Tags {
 		b.WriteString(fmt.Sprintf("<span class=\"tag\">%[1]s</span>", tag))
 	}
 	return b.String()
 }
 
+func (r *HTML) planning(n *parser.Heading) string {
+	items := make([]string, 0, 3)
+	for _, item := range []struct {
+		keyword   string
+		timestamp *parser.InlineTimestamp
+	}{
+		{"CLOSED", n.Closed},
+		{"DEADLINE", n.Deadline},
+		{"SCHEDULED", n.Scheduled},
+	} {
+		if item.timestamp == nil {
+			continue
+		}
+		items = append(items, fmt.Sprintf("<span class=\"planning-keyword\">%s:</span> %s", item.keyword, r.inlineTimestamp(item.timestamp)))
+	}
+	return fmt.Sprintf("<p class=\"planning\">%s</p>", strings.Join(items, " "))
+}
+
 func (r *HTML) RenderHeading(n *parser.Heading) string {
+	if !n.IsExported() {
+		return ""
+	}
+	if r.isListHeading(n) {
+		return r.listHeading(n)
+	}
 	var b strings.Builder
 
 	b.WriteString(fmt.Sprintf("<h%[1]d id=\"%s\">", n.Stars+r.HeadingOffset, n.Id()))
-	b.WriteString(r.heading(n))
-	b.WriteString(fmt.Sprintf("</h%[1]d>", n.Stars))
-	if len(n.Children) > 0 {
+	b.WriteString(r.heading(n, false))
+	b.WriteString(fmt.Sprintf("</h%[1]d>", n.Stars+r.HeadingOffset))
+	if n.HasPlanning() && !r.HidePlanning && r.opts().Planning {
 		b.WriteString("\n")
+		b.WriteString(r.planning(n))
 	}
-	b.WriteString(r.RenderNodes(n.Children, "\n"))
+	if content := r.RenderNodes(n.Children, "\n"); content != "" {
+		b.WriteString("\n")
+		b.WriteString(content)
+	}
+	return b.String()
+}
+
+// listHeading renders the heading deeper than "H" of #+OPTIONS as a list
+// item, which is wrapped in a list by RenderNodes
+func (r *HTML) listHeading(n *parser.Heading) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<li id=\"%s\">", n.Id()))
+	b.WriteString(r.heading(n, false))
+	if content := r.RenderNodes(n.Children, "\n"); content != "" {
+		b.WriteString("\n")
+		b.WriteString(content)
+	}
+	b.WriteString("</li>")
 	return b.String()
 }
 
@@ -144,26 +361,83 @@ func (r *HTML) RenderKeyword(n *parser.Keyword) string {
 }
 
 func (r *HTML) RenderListItem(n *parser.ListItem) string {
-	content := r.RenderNodes(n.Children, "\n")
-	if n.Status != "" {
-		if strings.HasPrefix(content, "<p>") {
-			content = fmt.Sprintf("<p><code>[%[1]s]</code>", n.Status) + content[3:]
-		} else {
-			content = fmt.Sprintf("<code>[%[1]s]</code>", n.Status) + content
-		}
+	var b strings.Builder
+
+	status := checkbox(n.Status)
+	if len(n.Title) > 0 {
+		b.WriteString("<p>\n")
+		b.WriteString(status)
+		b.WriteString(r.RenderNodes(n.Title, ""))
+		b.WriteString("\n</p>\n")
+	} else {
+		b.WriteString(status)
+	}
+	b.WriteString(r.RenderNodes(n.Children, "\n"))
+
+	attrs := make(map[string]string)
+	if n.Counter != "" {
+		attrs["value"] = strconv.Itoa(n.Start())
+	}
+	return fmt.Sprintf("<li%[2]s>\n%[1]s\n</li>", strings.TrimRight(b.String(), "\n"), attributes(attrs, checkboxClass(n.Status)))
+}
+
+// checkbox renders the status of list item as a disabled checkbox
+func checkbox(status string) string {
+	switch status {
+	case "X":
+		return `<input type="checkbox" checked disabled /> `
+	case "-":
+		return `<input type="checkbox" class="indeterminate" aria-checked="mixed" disabled /> `
+	case " ":
+		return `<input type="checkbox" disabled /> `
+	default:
+		return ""
+	}
+}
+
+// checkboxClass returns the class of list item like ox-html
+func checkboxClass(status string) string {
+	switch status {
+	case "X":
+		return "on"
+	case "-":
+		return "trans"
+	case " ":
+		return "off"
+	default:
+		return ""
+	}
+}
+
+func (r *HTML) RenderDescriptiveItem(n *parser.DescriptiveItem) string {
+	term := checkbox(n.Status) + r.RenderNodes(n.Title, "")
+	if len(n.Children) == 0 {
+		return fmt.Sprintf("<dt>%[1]s</dt>\n<dd></dd>", term)
 	}
-	return fmt.Sprintf("<li>\n%[1]s</li>", content)
+	return fmt.Sprintf("<dt>%[1]s</dt>\n<dd>\n%[2]s\n</dd>", term, r.RenderNodes(n.Children, "\n"))
 }
 
 func (r *HTML) RenderList(n *parser.List) string {
 	content := r.RenderNodes(n.Children, "\n")
+	attrs := affiliatedAttrs(n.Affiliated)
 	switch n.Type {
 	case parser.OrderlistName:
-		return fmt.Sprintf("<ol>\n%[1]s\n</ol>", content)
+		if item, ok := n.Children[0].(*parser.ListItem); ok {
+			if item.Counter != "" {
+				attrs["start"] = strconv.Itoa(item.Start())
+			}
+			// alphabetical bullets
+			if c := item.Bullet[0]; c >= 'a' && c <= 'z' {
+				attrs["type"] = "a"
+			} else if c >= 'A' && c <= 'Z' {
+				attrs["type"] = "A"
+			}
+		}
+		return fmt.Sprintf("<ol%[2]s>\n%[1]s\n</ol>", content, attributes(attrs, ""))
 	case parser.UnorderlistName:
-		return fmt.Sprintf("<ul>\n%[1]s\n</ul>", content)
+		return fmt.Sprintf("<ul%[2]s>\n%[1]s\n</ul>", content, attributes(attrs, ""))
 	case parser.DescriptiveName:
-		return fmt.Sprintf("<dl>\n%[1]s\n</dl>", content)
+		return fmt.Sprintf("<dl%[2]s>\n%[1]s\n</dl>", content, attributes(attrs, ""))
 	default:
 		return ""
 	}
@@ -188,10 +462,20 @@ func (r *HTML) RenderTableRow(n *parser.TableRow) string {
 }
 
 func (r *HTML) RenderTable(n *parser.Table) string {
-	return fmt.Sprintf("<table>\n%[1]s\n</table>", r.RenderNodes(n.Children, "\n"))
+	caption := ""
+	if n.Affiliated != nil && len(n.Affiliated.Caption) > 0 {
+		caption = fmt.Sprintf("\n<caption>%s</caption>", r.RenderNodes(n.Affiliated.Caption, ""))
+	}
+	attrs := attributes(affiliatedAttrs(n.Affiliated), "")
+	return fmt.Sprintf("<table%[2]s>%[3]s\n%[1]s\n</table>", r.RenderNodes(n.Children, "\n"), attrs, caption)
 }
 
 func (r *HTML) RenderBlock(n *parser.Block) string {
+	return r.figure(r.block(n), n.Affiliated)
+}
+
+func (r *HTML) block(n *parser.Block) string {
+	attrs := affiliatedAttrs(n.Affiliated)
 	switch n.Type {
 	case "SRC":
 		lang := "unknown"
@@ -199,35 +483,137 @@ func (r *HTML) RenderBlock(n *parser.Block) string {
 			lang = n.Parameters[0]
 		}
 		text := htmlEscape(DedentString(r.RenderNodes(n.Children, "\n")))
-		return fmt.Sprintf("<pre class=\"src src-%[1]s\">%[2]s</pre>", lang, text)
+		return fmt.Sprintf("<pre%[1]s>%[2]s</pre>", attributes(attrs, "src src-"+lang), text)
 	case "EXAMPLE":
 		text := htmlEscape(DedentString(r.RenderNodes(n.Children, "\n")))
-		return fmt.Sprintf("<pre class=\"src src-example\">%[1]s</pre>", text)
+		return fmt.Sprintf("<pre%[1]s>%[2]s</pre>", attributes(attrs, "src src-example"), text)
 	case "CENTER":
-		return fmt.Sprintf("<div style=\"text-align:center;\">\n%[1]s\n</div>", r.RenderNodes(n.Children, "\n"))
+		return fmt.Sprintf("<div style=\"text-align:center;\"%[2]s>\n%[1]s\n</div>", r.RenderNodes(n.Children, "\n"), attributes(attrs, ""))
 	case "QUOTE":
-		return fmt.Sprintf("<blockquote>\n%[1]s\n</blockquote>", r.RenderNodes(n.Children, "\n"))
+		return fmt.Sprintf("<blockquote%[2]s>\n%[1]s\n</blockquote>", r.RenderNodes(n.Children, "\n"), attributes(attrs, ""))
 	case "EXPORT":
 		return r.RenderNodes(n.Children, "\n")
 	case "VERSE":
 		var b strings.Builder
 		for _, child := range n.Children {
 			if child.Name() == parser.InlineLineBreakName {
-				b.WriteString(strings.Repeat("<br />", child.(*parser.InlineLineBreak).Count))
+				b.WriteString(strings.Repeat("<br />\n", child.(*parser.InlineLineBreak).Count))
 				continue
 			}
 			b.WriteString(r.RenderNode(child, false))
 		}
-		return fmt.Sprintf("<p>\n%[1]s\n</p>", b.String())
+		return fmt.Sprintf("<p%[2]s>\n%[1]s\n</p>", b.String(), attributes(attrs, ""))
 	}
-	return fmt.Sprintf("<div class=\"%[1]s-block\">\n%[2]s\n</div>", strings.ToLower(n.Type), r.RenderNodes(n.Children, "\n"))
+	return fmt.Sprintf("<div%[1]s>\n%[2]s\n</div>", attributes(attrs, strings.ToLower(n.Type)+"-block"), r.RenderNodes(n.Children, "\n"))
 }
 
 func (r *HTML) RenderBlockResult(n *parser.BlockResult) string {
 	return r.RenderNodes(n.Children, "\n")
 }
 
+func (r *HTML) RenderLatexFragment(n *parser.LatexFragment) string {
+	if r.RenderLatexFunc != nil {
+		if out, err := r.RenderLatexFunc(n.Content, n.Display()); err == nil {
+			return out
+		}
+	}
+	if n.Display() {
+		return fmt.Sprintf(`<span class="math display">\[%s\]</span>`, htmlEscape(n.Content))
+	}
+	return fmt.Sprintf(`<span class="math inline">\(%s\)</span>`, htmlEscape(n.Content))
+}
+
+func (r *HTML) RenderEntity(n *parser.Entity) string {
+	if !r.opts().Entities {
+		if n.Brackets {
+			return "\\" + n.Def.Name + "{}"
+		}
+		return "\\" + n.Def.Name
+	}
+	return n.Def.HTML
+}
+
+func (r *HTML) RenderSubscript(n *parser.Subscript) string {
+	return fmt.Sprintf("<sub>%s</sub>", r.RenderNodes(n.Children, ""))
+}
+
+func (r *HTML) RenderSuperscript(n *parser.Superscript) string {
+	return fmt.Sprintf("<sup>%s</sup>", r.RenderNodes(n.Children, ""))
+}
+
+func (r *HTML) RenderMacro(n *parser.Macro) string {
+	return r.RenderNodes(n.Children, "")
+}
+
+func (r *HTML) RenderInlineSrc(n *parser.InlineSrc) string {
+	if n.Headers["exports"] == "none" {
+		return ""
+	}
+	return fmt.Sprintf(`<code class="src src-%s">%s</code>`, htmlEscape(n.Lang), htmlEscape(n.Content))
+}
+
+// RenderInlineCall renders the call itself, since code blocks are not
+// evaluated.
+func (r *HTML) RenderInlineCall(n *parser.InlineCall) string {
+	if n.EndHeaderArgs["exports"] == "none" {
+		return ""
+	}
+	return fmt.Sprintf(`<code class="call">%s(%s)</code>`, htmlEscape(n.Call), htmlEscape(n.Args))
+}
+
+func (r *HTML) RenderTarget(n *parser.Target) string {
+	return fmt.Sprintf(`<span id="%s"></span>`, n.Id())
+}
+
+func (r *HTML) RenderRadioTarget(n *parser.RadioTarget) string {
+	return fmt.Sprintf(`<span id="%s">%s</span>`, n.Id(), r.RenderNodes(n.Children, ""))
+}
+
+// RenderExportSnippet only renders the snippets of html backend
+func (r *HTML) RenderExportSnippet(n *parser.ExportSnippet) string {
+	if n.Backend != "html" {
+		return ""
+	}
+	return n.Value
+}
+
+// RenderExportKeyword only renders #+HTML
+func (r *HTML) RenderExportKeyword(n *parser.ExportKeyword) string {
+	if n.Backend != "html" {
+		return ""
+	}
+	return n.Value
+}
+
+func (r *HTML) RenderInclude(n *parser.Include) string {
+	return r.RenderNodes(n.Children, "\n")
+}
+
+func (r *HTML) RenderLatexEnvironment(n *parser.LatexEnvironment) string {
+	tex := DedentString(n.Content)
+	if r.RenderLatexFunc != nil {
+		if out, err := r.RenderLatexFunc(tex, true); err == nil {
+			return out
+		}
+	}
+	return fmt.Sprintf("<div class=\"math display\">\n%s\n</div>", htmlEscape(tex))
+}
+
+func (r *HTML) RenderFixedWidth(n *parser.FixedWidth) string {
+	if !r.opts().FixedWidth {
+		return ""
+	}
+	return fmt.Sprintf("<pre class=\"example\">%s</pre>", htmlEscape(n.Content))
+}
+
+func (r *HTML) RenderComment(n *parser.Comment) string {
+	return ""
+}
+
 func (r *HTML) RenderDrawer(n *parser.Drawer) string {
+	if !r.opts().Drawers {
+		return ""
+	}
 	return r.RenderNodes(n.Children, "\n")
 }
 
@@ -240,10 +626,20 @@ func (r *HTML) RenderHr(n *parser.Hr) string {
 }
 
 func (r *HTML) RenderParagraph(n *parser.Paragragh) string {
+	if n.Affiliated != nil {
+		link := r.link(n.Link(), attributes(affiliatedAttrs(n.Affiliated), ""))
+		if len(n.Affiliated.Caption) > 0 {
+			return r.figure(link, n.Affiliated)
+		}
+		return fmt.Sprintf("<p>\n%[1]s\n</p>", link)
+	}
 	return fmt.Sprintf("<p>\n%[1]s\n</p>", r.RenderNodes(n.Children, ""))
 }
 
 func (r *HTML) RenderFootnote(n *parser.Footnote) string {
+	if !r.opts().Footnotes {
+		return ""
+	}
 	if len(n.Definition) > 0 {
 		r.fnList = append(r.fnList, n)
 	}
@@ -283,23 +679,23 @@ func (r *HTML) RenderFootnotes(fns []*parser.Footnote, used map[string]bool) str
 }
 
 func (r *HTML) RenderSection(n *parser.Section) string {
-	if len(n.Children) == 0 {
-		return ""
-	}
-
 	var b strings.Builder
-
-	b.WriteString("<ul>\n")
 	for _, section := range n.Children {
-		b.WriteString(fmt.Sprintf(`<li><a href="#%s">%s</a>`, section.Id(), r.heading(section.Heading)))
-		if len(section.Children) > 0 {
+		if !section.IsExported() || section.Stars > r.opts().Toc {
+			continue
+		}
+		b.WriteString(fmt.Sprintf(`<li><a href="#%s">%s</a>`, section.Id(), r.heading(section.Heading, true)))
+		if toc := r.RenderSection(section); toc != "" {
 			b.WriteString("\n")
-			b.WriteString(r.RenderSection(section))
+			b.WriteString(toc)
 		}
 		b.WriteString("</li>\n")
 	}
-	b.WriteString("</ul>")
-	return b.String()
+	if b.Len() == 0 {
+		return ""
+	}
+	return "<ul>\n" + b.String() + "</ul>"
+
 }
 
 func (r *HTML) String() string {
@@ -310,12 +706,41 @@ func (r *HTML) String() string {
 		content = content + fn
 	}
 
-	if !r.Toc || r.Document.Get("toc") == "nil" {
-		return content
+	if r.Title {
+		if postamble := r.postamble(); postamble != "" {
+			content = content + "\n" + postamble
+		}
 	}
-	if toc := r.RenderNode(r.Document.Sections, false); toc != "" {
-		toc = fmt.Sprintf(`<div id="table-of-contents"><h2>Table of Contents</h2><div id="text-table-of-contents">%s</div></div>`, toc)
-		return toc + "\n" + content
+	if r.Toc && r.opts().Toc != 0 {
+		if toc := r.RenderNode(r.Document.Sections, false); toc != "" {
+			toc = fmt.Sprintf(`<div id="table-of-contents"><h2>Table of Contents</h2><div id="text-table-of-contents">%s</div></div>`, toc)
+			content = toc + "\n" + content
+		}
+	}
+	if r.Title && r.opts().Title {
+		if title := r.Document.Get("TITLE"); title != "" {
+			content = fmt.Sprintf(`<h1 class="title">%s</h1>`, htmlEscape(title)) + "\n" + content
+		}
 	}
 	return content
 }
+
+// postamble renders #+AUTHOR, #+EMAIL and #+DATE of the document
+func (r *HTML) postamble() string {
+	var b strings.Builder
+
+	opts := r.opts()
+	if author := r.Document.Get("AUTHOR"); opts.Author && author != "" {
+		fmt.Fprintf(&b, "<p class=\"author\">Author: %s</p>\n", htmlEscape(author))
+	}
+	if email := r.Document.Get("EMAIL"); opts.Email && email != "" {
+		fmt.Fprintf(&b, "<p class=\"email\">Email: <a href=\"mailto:%[1]s\">%[1]s</a></p>\n", htmlEscape(email))
+	}
+	if date := r.Document.Get("DATE"); opts.Date && date != "" {
+		fmt.Fprintf(&b, "<p class=\"date\">Date: %s</p>\n", htmlEscape(date))
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "<div id=\"postamble\">\n" + b.String() + "</div>"
+}
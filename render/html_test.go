@@ -1,9 +1,13 @@
 package render
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/honmaple/org-golang/parser"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,3 +30,273 @@ func TestHTML(t *testing.T) {
 		assert.Equal(t, string(expect), out.String())
 	}
 }
+
+func TestHTMLAffiliated(t *testing.T) {
+	text := `#+NAME: fig
+#+CAPTION[Short]: A *cat*
+#+ATTR_HTML: :width 300 :class photo
+[[file:cat.png]]
+
+#+CAPTION: Numbers
+#+ATTR_HTML: :border 1
+| a | b |
+
+#+ATTR_HTML: :class steps
+- one
+
+#+NAME: orphan
+Some text`
+
+	expect := `<figure>
+<img src="cat.png" alt="cat.png" class="photo" id="fig" width="300"/>
+<figcaption>A <b>cat</b></figcaption>
+</figure>
+
+<table border="1">
+<caption>Numbers</caption>
+<tr>
+<td>a</td>
+<td>b</td>
+</tr>
+</table>
+
+<ul class="steps">
+<li>
+<p>
+one
+</p>
+</li>
+</ul>
+<p>
+Some text
+</p>`
+	out := HTML{Document: toDocument([]byte(text))}
+	assert.Equal(t, expect, out.String())
+}
+
+func TestHTMLTimestampFormat(t *testing.T) {
+	text := `* Trip
+  SCHEDULED: <2026-10-17 Sat>
+From <2026-10-17 Sat 08:00> to <2026-10-17 Sat>--<2026-10-19 Mon>`
+
+	expect := `<h1 id="heading-1">Trip</h1>
+<p>
+From <time class="timestamp active" datetime="2026-10-17T08:00">Oct 17, 08:00</time> to <time class="timestamp active range" datetime="2026-10-17">Oct 17&ndash;Oct 19</time>
+</p>`
+	out := HTML{
+		Document:        toDocument([]byte(text)),
+		HidePlanning:    true,
+		TimestampFormat: "Jan 2, 15:04",
+		DateFormat:      "Jan 2",
+	}
+	assert.Equal(t, expect, out.String())
+
+	// the timestamp with time of day keeps the original text if only
+	// DateFormat is set
+	out.TimestampFormat = ""
+	expect = `<h1 id="heading-1">Trip</h1>
+<p>
+From <time class="timestamp active" datetime="2026-10-17T08:00">&lt;2026-10-17 Sat 08:00&gt;</time> to <time class="timestamp active range" datetime="2026-10-17">Oct 17&ndash;Oct 19</time>
+</p>`
+	assert.Equal(t, expect, out.String())
+}
+
+func TestHTMLDescriptiveList(t *testing.T) {
+	text := `- *Go* :: a language
+- Lisp ::`
+
+	expect := `<dl>
+<dt><b>Go</b></dt>
+<dd>
+<p>
+a language
+</p>
+</dd>
+<dt>Lisp</dt>
+<dd></dd>
+</dl>`
+	out := HTML{Document: toDocument([]byte(text))}
+	assert.Equal(t, expect, out.String())
+}
+
+func TestHTMLLatexFunc(t *testing.T) {
+	text := `\(x\) and $y$`
+
+	out := HTML{
+		Document: toDocument([]byte(text)),
+		RenderLatexFunc: func(tex string, display bool) (string, error) {
+			if tex == "y" {
+				return "", errors.New("unsupported")
+			}
+			return fmt.Sprintf("<katex>%s</katex>", tex), nil
+		},
+	}
+	assert.Equal(t, "<p>\n<katex>x</katex> and <span class=\"math inline\">\\(y\\)</span>\n</p>", out.String())
+}
+
+func TestHTMLInternalLink(t *testing.T) {
+	text := `* Intro
+:PROPERTIES:
+:CUSTOM_ID: intro
+:END:
+See [[*Intro]], [[#intro][the intro]] and [[here]].
+A <<here>> target and a <<<Radio Link>>>, which links the radio link.`
+
+	expect := `<h1 id="intro">Intro</h1>
+<p>
+See <a href="#intro">Intro</a>, <a href="#intro">the intro</a> and <a href="#target-here">here</a>.
+A <span id="target-here"></span> target and a <span id="target-radio-link">Radio Link</span>, which links the <a href="#target-radio-link">radio link</a>.
+</p>`
+	out := HTML{Document: toDocument([]byte(text))}
+	assert.Equal(t, expect, out.String())
+}
+
+func TestHTMLExportSnippet(t *testing.T) {
+	text := `Press @@html:<kbd>@@C-c@@html:</kbd>@@ @@latex:\LaTeX{}@@
+#+HTML: <hr class="fancy"/>
+#+LATEX: \newpage`
+
+	expect := `<p>
+Press <kbd>C-c</kbd> 
+</p>
+<hr class="fancy"/>`
+	out := HTML{Document: toDocument([]byte(text))}
+	assert.Equal(t, expect, out.String())
+}
+
+func TestHTMLLinkHandler(t *testing.T) {
+	d := toDocument(nil)
+	d.SetLinkHandler("attachment", func(d *parser.Document, link *parser.InlineLink) (string, string, parser.LinkType) {
+		return "/data/" + link.URL, link.Desc, parser.ImageLink
+	})
+	d.Children = parser.ParseFromText(d, "[[attachment:cat][A cat]] and doi:10.1000/182")
+
+	expect := `<p>
+<img src="/data/cat" alt="cat"/> and doi:10.1000/182
+</p>`
+	out := HTML{Document: d}
+	assert.Equal(t, expect, out.String())
+
+	d.SetLinkHandler("doi", parser.PrefixLinkHandler("https://doi.org/"))
+	d.Children = parser.ParseFromText(d, "doi:10.1000/182")
+	assert.Equal(t, "<p>\n<a href=\"https://doi.org/10.1000/182\">https://doi.org/10.1000/182</a>\n</p>", out.String())
+}
+
+func TestHTMLOptions(t *testing.T) {
+	text := `#+OPTIONS: num:t H:2 toc:1 todo:nil tags:not-in-toc broken-links:mark
+* TODO Intro :tag:
+See [[missing]].
+** Details
+*** Deep
+**** Deeper
+*** Deep 2
+* Private :noexport:
+Hidden`
+
+	expect := `<div id="table-of-contents"><h2>Table of Contents</h2><div id="text-table-of-contents"><ul>
+<li><a href="#heading-1"><span class="section-number-1">1</span> Intro</a></li>
+</ul></div></div>
+<h1 id="heading-1"><span class="section-number-1">1</span> Intro<span class="tag">tag</span></h1>
+<p>
+See [BROKEN LINK: missing].
+</p>
+<h2 id="heading-1.1"><span class="section-number-2">1.1</span> Details</h2>
+<ul>
+<li id="heading-1.1.1">Deep
+<ul>
+<li id="heading-1.1.1.1">Deeper</li>
+</ul></li>
+<li id="heading-1.1.2">Deep 2</li>
+</ul>`
+	out := HTML{Document: toDocument([]byte(text)), Toc: true}
+	assert.Equal(t, expect, out.String())
+}
+
+func TestHTMLTitle(t *testing.T) {
+	text := `#+TITLE: A <Title>
+#+AUTHOR: honmaple
+#+EMAIL: mail@example.com
+#+DATE: 2022-01-01
+text`
+
+	expect := `<h1 class="title">A &lt;Title&gt;</h1>
+<p>
+text
+</p>
+<div id="postamble">
+<p class="author">Author: honmaple</p>
+<p class="date">Date: 2022-01-01</p>
+</div>`
+	out := HTML{Document: toDocument([]byte(text)), Title: true}
+	assert.Equal(t, expect, out.String())
+
+	out = HTML{Document: toDocument([]byte("#+OPTIONS: title:nil author:nil email:t\n" + text)), Title: true}
+	assert.Equal(t, `<p>
+text
+</p>
+<div id="postamble">
+<p class="email">Email: <a href="mailto:mail@example.com">mail@example.com</a></p>
+<p class="date">Date: 2022-01-01</p>
+</div>`, out.String())
+
+	out = HTML{Document: toDocument([]byte(text))}
+	assert.Equal(t, "<p>\ntext\n</p>", out.String())
+}
+
+func TestHTMLDeepHeadings(t *testing.T) {
+	text := `* 1
+** 2
+*** 3
+**** 4
+***** 5`
+
+	expect := `<div id="table-of-contents"><h2>Table of Contents</h2><div id="text-table-of-contents"><ul>
+<li><a href="#heading-1">1</a>
+<ul>
+<li><a href="#heading-1.1">2</a>
+<ul>
+<li><a href="#heading-1.1.1">3</a>
+<ul>
+<li><a href="#heading-1.1.1.1">4</a>
+<ul>
+<li><a href="#heading-1.1.1.1.1">5</a></li>
+</ul></li>
+</ul></li>
+</ul></li>
+</ul></li>
+</ul></div></div>
+<h1 id="heading-1">1</h1>
+<h2 id="heading-1.1">2</h2>
+<h3 id="heading-1.1.1">3</h3>
+<h4 id="heading-1.1.1.1">4</h4>
+<h5 id="heading-1.1.1.1.1">5</h5>`
+	out := HTML{Document: toDocument([]byte(text)), Toc: true}
+	assert.Equal(t, expect, out.String())
+}
+
+type mapFS map[string]string
+
+func (fs mapFS) ReadFile(name string) ([]byte, error) {
+	if text, ok := fs[name]; ok {
+		return []byte(text), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func TestHTMLInclude(t *testing.T) {
+	d := toDocument(nil)
+	d.FS = mapFS{"main.go": "package main\n\n/*\n#+END_SRC\n* not a heading\n,* comma\n*/\n"}
+	d.Children = parser.ParseFromText(d, `#+INCLUDE: "main.go" src go :lines "3-8"
+#+begin_example
+,#+end_example
+#+end_example`)
+
+	expect := `<pre class="src src-go">/*
+#+END_SRC
+* not a heading
+,* comma
+*/</pre>
+<pre class="src src-example">#+end_example</pre>`
+	out := HTML{Document: d}
+	assert.Equal(t, expect, out.String())
+}
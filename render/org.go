@@ -1,11 +1,17 @@
 package render
 
 import (
+	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/honmaple/org-golang/parser"
 )
 
+// orgBlockEscapeRegexp matches the lines in src and example blocks which
+// must be escaped with a comma
+var orgBlockEscapeRegexp = regexp.MustCompile(`(?m)^([ \t]*)(,?(?:\*|#\+))`)
+
 type Org struct {
 	Document       *parser.Document
 	RenderNodeFunc func(r Renderer, n parser.Node) string
@@ -22,7 +28,12 @@ func (r *Org) RenderNodes(children []parser.Node, sep string) string {
 	return RenderNodes(r, children, sep)
 }
 
-func (r *Org) RenderInlineLink(*parser.InlineLink) string {
+// RenderInlineLink only renders the text of radio links, which is
+// linked to the radio target automatically.
+func (r *Org) RenderInlineLink(n *parser.InlineLink) string {
+	if n.Protocol == "radio" {
+		return n.Desc
+	}
 	return ""
 }
 
@@ -30,8 +41,8 @@ func (r *Org) RenderInlineText(n *parser.InlineText) string {
 	return n.Content
 }
 
-func (r *Org) RenderInlinePercent(*parser.InlinePercent) string {
-	return ""
+func (r *Org) RenderInlinePercent(n *parser.InlinePercent) string {
+	return "[" + n.Num + "]"
 }
 
 func (r *Org) RenderInlineEmphasis(n *parser.InlineEmphasis) string {
@@ -43,12 +54,12 @@ func (r *Org) RenderInlineEmphasis(n *parser.InlineEmphasis) string {
 	return b.String()
 }
 
-func (r *Org) RenderInlineTimestamp(*parser.InlineTimestamp) string {
-	return ""
+func (r *Org) RenderInlineTimestamp(n *parser.InlineTimestamp) string {
+	return n.Content
 }
 
 func (r *Org) RenderInlineLineBreak(n *parser.InlineLineBreak) string {
-	return strings.Repeat("\\", n.Count)
+	return strings.Repeat("\n", n.Count)
 }
 
 func (r *Org) RenderInlineBackSlash(n *parser.InlineBackSlash) string {
@@ -76,8 +87,13 @@ func (r *Org) RenderHeading(n *parser.Heading) string {
 		b.WriteString(n.Priority)
 		b.WriteString("]")
 	}
-	b.WriteString(" ")
-	b.WriteString(r.RenderNodes(n.Title, ""))
+	if n.Commented {
+		b.WriteString(" COMMENT")
+	}
+	if len(n.Title) > 0 {
+		b.WriteString(" ")
+		b.WriteString(r.RenderNodes(n.Title, ""))
+	}
 	if len(n.Tags) > 0 {
 		b.WriteString(" :")
 		for _, tag := range n.Tags {
@@ -85,41 +101,140 @@ func (r *Org) RenderHeading(n *parser.Heading) string {
 			b.WriteString(":")
 		}
 	}
-	b.WriteString("\n")
-	b.WriteString(r.RenderNodes(n.Children, "\n"))
+	if n.HasPlanning() {
+		b.WriteString("\n")
+		b.WriteString(strings.Repeat(" ", n.Stars+1))
+		b.WriteString(r.planning(n))
+	}
+	if len(n.Children) > 0 {
+		b.WriteString("\n")
+		b.WriteString(r.RenderNodes(n.Children, "\n"))
+	}
 	return b.String()
 }
 
+func (r *Org) planning(n *parser.Heading) string {
+	items := make([]string, 0, 3)
+	if n.Closed != nil {
+		items = append(items, "CLOSED: "+r.RenderNode(n.Closed, false))
+	}
+	if n.Deadline != nil {
+		items = append(items, "DEADLINE: "+r.RenderNode(n.Deadline, false))
+	}
+	if n.Scheduled != nil {
+		items = append(items, "SCHEDULED: "+r.RenderNode(n.Scheduled, false))
+	}
+	return strings.Join(items, " ")
+}
+
 func (r *Org) RenderListItem(n *parser.ListItem) string {
 	var b strings.Builder
+
+	b.WriteString(strings.Repeat(" ", n.Level))
+	b.WriteString(n.Bullet)
+	if n.Counter != "" {
+		b.WriteString(" [@")
+		b.WriteString(n.Counter)
+		b.WriteString("]")
+	}
 	if n.Status != "" {
-		b.WriteString("[")
+		b.WriteString(" [")
 		b.WriteString(n.Status)
 		b.WriteString("]")
 	}
-	b.WriteString(r.RenderNodes(n.Children, "\n"))
+	if len(n.Title) > 0 {
+		b.WriteString(" ")
+		b.WriteString(r.RenderNodes(n.Title, ""))
+	}
+	if len(n.Children) > 0 {
+		b.WriteString("\n")
+		b.WriteString(r.RenderNodes(n.Children, "\n"))
+	}
+	return b.String()
+}
+
+func (r *Org) RenderDescriptiveItem(n *parser.DescriptiveItem) string {
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", n.Level))
+	b.WriteString(n.Bullet)
+	b.WriteString(" ")
+	if n.Status != "" {
+		b.WriteString("[")
+		b.WriteString(n.Status)
+		b.WriteString("] ")
+	}
+	b.WriteString(r.RenderNodes(n.Title, ""))
+	b.WriteString(" ::")
+	if len(n.Children) > 0 {
+		b.WriteString(" ")
+		b.WriteString(r.RenderNodes(n.Children, "\n"))
+	}
 	return b.String()
 }
 
 func (r *Org) RenderList(n *parser.List) string {
-	return r.RenderNodes(n.Children, "\n")
+	return r.affiliated(n.Affiliated) + r.RenderNodes(n.Children, "\n")
 }
 
 func (r *Org) RenderTableColumn(n *parser.TableColumn) string {
-	return ""
+	return r.RenderNodes(n.Children, "")
 }
 
 func (r *Org) RenderTableRow(n *parser.TableRow) string {
 	return ""
 }
 
+// RenderTable renders the table with aligned columns
 func (r *Org) RenderTable(n *parser.Table) string {
-	return ""
+	rows := make([][]string, len(n.Children))
+	widths := make([]int, 0)
+	for i, child := range n.Children {
+		row := child.(*parser.TableRow)
+		cells := make([]string, 0, len(row.Children))
+		for _, info := range row.Infos {
+			cells = append(cells, "<"+info+">")
+		}
+		for _, column := range row.Children {
+			cells = append(cells, r.RenderNode(column, false))
+		}
+		for j, cell := range cells {
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := utf8.RuneCountInString(cell); w > widths[j] {
+				widths[j] = w
+			}
+		}
+		rows[i] = cells
+	}
+
+	lines := make([]string, len(rows))
+	for i, cells := range rows {
+		if n.Children[i].(*parser.TableRow).Separator {
+			seps := make([]string, len(widths))
+			for j, w := range widths {
+				seps[j] = strings.Repeat("-", w+2)
+			}
+			lines[i] = "|" + strings.Join(seps, "+") + "|"
+			continue
+		}
+		padded := make([]string, len(widths))
+		for j, w := range widths {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			padded[j] = cell + strings.Repeat(" ", w-utf8.RuneCountInString(cell))
+		}
+		lines[i] = "| " + strings.Join(padded, " | ") + " |"
+	}
+	return r.affiliated(n.Affiliated) + strings.Join(lines, "\n")
 }
 
 func (r *Org) RenderBlock(n *parser.Block) string {
 	var b strings.Builder
 
+	b.WriteString(r.affiliated(n.Affiliated))
 	b.WriteString("#+begin_")
 	b.WriteString(strings.ToLower(n.Type))
 	for _, param := range n.Parameters {
@@ -128,9 +243,12 @@ func (r *Org) RenderBlock(n *parser.Block) string {
 	}
 	b.WriteString("\n")
 	if len(n.Children) > 0 {
-		if n.Type == "VERSE" {
+		switch n.Type {
+		case "VERSE":
 			b.WriteString(r.RenderNodes(n.Children, ""))
-		} else {
+		case "SRC", "EXAMPLE":
+			b.WriteString(orgBlockEscapeRegexp.ReplaceAllString(r.RenderNodes(n.Children, "\n"), "$1,$2"))
+		default:
 			b.WriteString(r.RenderNodes(n.Children, "\n"))
 		}
 		b.WriteString("\n")
@@ -144,24 +262,162 @@ func (r *Org) RenderBlockResult(n *parser.BlockResult) string {
 	return ""
 }
 
+func (r *Org) RenderLatexFragment(n *parser.LatexFragment) string {
+	return n.Delimiter + n.Content + n.Close()
+}
+
+func (r *Org) RenderEntity(n *parser.Entity) string {
+	if n.Brackets {
+		return "\\" + n.Def.Name + "{}"
+	}
+	return "\\" + n.Def.Name
+}
+
+func (r *Org) RenderSubscript(n *parser.Subscript) string {
+	return r.script("_", n.Brackets, n.Children)
+}
+
+func (r *Org) RenderSuperscript(n *parser.Superscript) string {
+	return r.script("^", n.Brackets, n.Children)
+}
+
+func (r *Org) script(marker string, brackets bool, children []parser.Node) string {
+	if brackets {
+		return marker + "{" + r.RenderNodes(children, "") + "}"
+	}
+	return marker + r.RenderNodes(children, "")
+}
+
+func (r *Org) RenderMacro(n *parser.Macro) string {
+	if n.Args == nil {
+		return "{{{" + n.Key + "}}}"
+	}
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = strings.ReplaceAll(arg, ",", "\\,")
+	}
+	return "{{{" + n.Key + "(" + strings.Join(args, ",") + ")}}}"
+}
+
+func (r *Org) RenderInlineSrc(n *parser.InlineSrc) string {
+	if n.Parameters != "" {
+		return "src_" + n.Lang + "[" + n.Parameters + "]{" + n.Content + "}"
+	}
+	return "src_" + n.Lang + "{" + n.Content + "}"
+}
+
+func (r *Org) RenderInlineCall(n *parser.InlineCall) string {
+	var b strings.Builder
+
+	b.WriteString("call_")
+	b.WriteString(n.Call)
+	if n.InsideHeaders != "" {
+		b.WriteString("[" + n.InsideHeaders + "]")
+	}
+	b.WriteString("(" + n.Args + ")")
+	if n.EndHeaders != "" {
+		b.WriteString("[" + n.EndHeaders + "]")
+	}
+	return b.String()
+}
+
+func (r *Org) RenderTarget(n *parser.Target) string {
+	return "<<" + n.Content + ">>"
+}
+
+func (r *Org) RenderRadioTarget(n *parser.RadioTarget) string {
+	return "<<<" + r.RenderNodes(n.Children, "") + ">>>"
+}
+
+// RenderExportSnippet only renders the value of org snippets, like
+// exporting to org in emacs.
+func (r *Org) RenderExportSnippet(n *parser.ExportSnippet) string {
+	if n.Backend != "org" {
+		return ""
+	}
+	return n.Value
+}
+
+// RenderExportKeyword renders nothing, since there is no export keyword
+// of org backend.
+func (r *Org) RenderExportKeyword(n *parser.ExportKeyword) string {
+	return ""
+}
+
+// RenderInclude keeps the #+INCLUDE keyword instead of the included content
+func (r *Org) RenderInclude(n *parser.Include) string {
+	return "#+" + n.Key + ": " + n.Value
+}
+
+func (r *Org) RenderLatexEnvironment(n *parser.LatexEnvironment) string {
+	return n.Content
+}
+
+func (r *Org) RenderFixedWidth(n *parser.FixedWidth) string {
+	lines := strings.Split(n.Content, "\n")
+	for i, line := range lines {
+		level := n.Level
+		if i < len(n.Indents) {
+			level = n.Indents[i]
+		}
+		indent := strings.Repeat(" ", level)
+		if line == "" {
+			lines[i] = indent + ":"
+		} else {
+			lines[i] = indent + ": " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Org) RenderComment(n *parser.Comment) string {
+	if n.Block {
+		return "#+begin_comment\n" + n.Content + "\n#+end_comment"
+	}
+	lines := strings.Split(n.Content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "#"
+		} else {
+			lines[i] = "# " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (r *Org) RenderDrawer(n *parser.Drawer) string {
 	return ""
 }
 
 func (r *Org) RenderParagraph(n *parser.Paragragh) string {
-	return r.RenderNodes(n.Children, "")
+	return r.affiliated(n.Affiliated) + r.RenderNodes(n.Children, "")
 }
 
 func (r *Org) RenderBlankline(n *parser.Blankline) string {
-	return ""
+	return strings.Repeat("\n", n.Count-1)
 }
 
 func (r *Org) RenderHr(*parser.Hr) string {
 	return "-----"
 }
 
-func (r *Org) RenderKeyword(*parser.Keyword) string {
-	return ""
+func (r *Org) RenderKeyword(n *parser.Keyword) string {
+	if n.Value == "" {
+		return "#+" + n.Key + ":"
+	}
+	return "#+" + n.Key + ": " + n.Value
+}
+
+func (r *Org) affiliated(aff *parser.Affiliated) string {
+	if aff == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, keyword := range aff.Keywords {
+		b.WriteString(r.RenderKeyword(keyword))
+		b.WriteString("\n")
+	}
+	return b.String()
 }
 
 func (r *Org) RenderSection(*parser.Section) string {
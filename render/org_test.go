@@ -38,7 +38,7 @@ func toDocument(buf []byte) *parser.Document {
 		Keywords: map[string]string{
 			"TODO": "TODO | DONE | CANCELED",
 		},
-		Hyperlinks:      []string{"http", "https", "file"},
+		LinkHandlers:    parser.DefaultLinkHandlers(),
 		TimestampFormat: "2006-01-02 Mon 15:04",
 	}
 	d.Children = parser.Parse(d, bytes.NewBuffer(buf))
@@ -58,6 +58,20 @@ func TestOrg(t *testing.T) {
 	}
 }
 
+func TestOrgExportSnippet(t *testing.T) {
+	text := "A @@org:*b*@@ @@html:<br/>@@c\n#+HTML: <hr/>"
+
+	out := &Org{Document: toDocument([]byte(text))}
+	assert.Equal(t, "A *b* c\n", out.String())
+}
+
+func TestOrgBlockEscape(t *testing.T) {
+	text := "#+begin_src org\n,* heading\n  ,#+end_src\n#+end_src"
+
+	out := &Org{Document: toDocument([]byte(text))}
+	assert.Equal(t, text, out.String())
+}
+
 // BenchmarkSprintf-8		11847894			99.43 ns/op
 // BenchmarkPlus-8			1000000000			 0.2529 ns/op
 // BenchmarkBuilder-8		22237069			52.56 ns/op